@@ -1,20 +1,56 @@
 package main
 
 import (
+	"flag"
 	"log"
 
 	"github.com/julienrbrt/ut_research_project/generate"
 	"github.com/julienrbrt/ut_research_project/recipe"
+	"github.com/julienrbrt/ut_research_project/util"
 )
 
+//locationJitterKm is the standard deviation of the distance between users and their postcode centroid
+//users are kept in the area of their postcode (see generate.PopulationSampler)
+const locationJitterKm = 0.5
+
 func main() {
+	seed := flag.Int64("seed", 1, "seed of the users generation, the same seed and recipes generate the same users and orders")
+	communities := flag.Int("communities", 0, "number of taste communities, users tastes are independent of their location when 0")
+	communityRadius := flag.Float64("community-radius", 2, "radius in km of the influence of a taste community")
+	communityCorrelation := flag.Float64("community-correlation", 0.5, "share in [0, 1] of the taste of a user coming from its community")
+	municipalitiesPath := flag.String("municipalities", "data/municipalities.csv", "CSV file of the municipalities population with municipality, population, latitude and longitude columns (e.g. the CBS population table)")
+	postcodesPath := flag.String("postcodes", "data/postcodes.csv", "CSV file of the PC4 or PC6 centroids with postcode, latitude, longitude and municipality columns (e.g. the CBS PC4 table)")
+	flag.Parse()
+
+	//Locate users in real postcode areas proportionally to the population, loaded before the long scraping
+	postcodes, err := util.LoadPostcodes(*postcodesPath)
+	if err != nil {
+		log.Fatalf("%v\nThe postcodes dataset is not bundled: download the PC4 centroids (e.g. from CBS or PDOK) or use -postcodes config/postcodes_sample.csv for a small sample\n", err)
+	}
+	municipalities, err := util.LoadMunicipalities(*municipalitiesPath)
+	if err != nil {
+		log.Fatalf("%v\nThe municipalities dataset is not bundled: download the population of the municipalities (e.g. from CBS) or use -municipalities config/municipalities_sample.csv for a small sample\n", err)
+	}
+	locations, err := generate.NewPopulationSampler(municipalities, postcodes, locationJitterKm)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
 	//Scrape recipes
-	recipes, err := recipe.RecipesData(5000, "data/orders.csv")
+	recipes, err := recipe.RecipesData(5000, "data/recipes.csv", "data/ingredients.csv")
 	if err != nil {
 		log.Fatalln(err)
 	}
 	//Generate user data
-	err = generate.UsersData(10000, recipes, "data/users.csv", "data/orders.csv")
+	config := generate.DefaultConfig(10000)
+	config.Seed = *seed
+	config.Locations = locations
+	config.Communities = generate.CommunityConfig{
+		Count:       *communities,
+		RadiusKm:    *communityRadius,
+		Correlation: *communityCorrelation,
+	}
+	err = generate.UsersData(recipes, config, "data/users.csv", "data/orders.csv")
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/julienrbrt/ut_research_project/recommend"
+	"github.com/julienrbrt/ut_research_project/util"
+	"github.com/olekukonko/tablewriter"
+)
+
+//cookCommand recommends the recipes a cook should cook for its neighbors
+func cookCommand(arguments []string) {
+	flags := flag.NewFlagSet("vinaigrette cook", flag.ExitOnError)
+
+	//get options
+	aggregate := flags.String("aggregate", "sum", "aggregation of the predicted ratings of the neighbors: sum or coverage (of their top-k recipes)")
+	topK := flags.Int("top-k", 10, "number of recipes predicted the best for each neighbor counted by the coverage")
+	modelsPath := flags.String("models", "", "JSON file configuring the collaborative filtering models (default models when empty)")
+	modelName := flags.String("model", "SVD", "name of the model predicting the ratings")
+	travel := flags.String("travel", "", "neighbors are reached in maxDistance minutes on the road graph by walk or cycle instead of a km radius")
+	osmPath := flags.String("osm", "data/roads.osm", "OpenStreetMap extract of the roads used with -travel")
+	maxTime := flags.Int("max-time", 0, "maximum total time of the recommended recipes in minutes (no limit when 0)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: vinaigrette cook [options] cookID nbRecipes maxDistance\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(arguments)
+
+	//get arguments
+	args := flags.Args()
+	if len(args) < 3 {
+		fmt.Printf("Error: argument(s) missing, only received %d\n", len(args))
+		flags.Usage()
+		os.Exit(1)
+	}
+	cookID, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Printf("Error: cookID must be an integer: %v\n", err)
+		os.Exit(1)
+	}
+	nbRecipes, err := strconv.Atoi(args[1])
+	if err != nil {
+		fmt.Printf("Error: nbRecipes must be an integer: %v\n", err)
+		os.Exit(1)
+	}
+	maxDistance, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		fmt.Printf("Error: maxDistance must be a number: %v\n", err)
+		os.Exit(1)
+	}
+	aggregation, err := recommend.ParseAggregation(*aggregate)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	//load model
+	registry := recommend.DefaultRegistry()
+	if *modelsPath != "" {
+		registry, err = recommend.LoadRegistry(*modelsPath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+	models, err := registry.Build(*modelName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//load datasets
+	log.Printf("Loading datasets...\n")
+	users := util.LoadCSV("data/users.csv")
+	orders := util.LoadCSV("data/orders.csv")
+	recipes := util.LoadCSV("data/recipes.csv")
+
+	neighborhood, unit, err := newNeighborhood(*travel, *osmPath, maxDistance, users)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	neighborsUsers := neighborhood.Neighbors(cookID)
+	fmt.Printf("There is %d neighboring users from cook %d in %.0f %s\n", neighborsUsers.Nrow(), cookID, maxDistance, unit)
+
+	filter, err := recommend.Context{MaxTime: *maxTime}.Filter(recipes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	recommendations, err := recommend.RecommendForCook(cookID, nbRecipes, models[0].Model, aggregation, *topK, filter, neighborsUsers, orders, recipes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	titles := make(map[string]string)
+	for i, id := range recipes.Col("id").Records() {
+		titles[id] = recipes.Col("title").Elem(i).String()
+	}
+
+	//print table
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Recipe", "Title", "Mean Predicted Rating", fmt.Sprintf("Neighbors Top %d", *topK)})
+	for _, r := range recommendations {
+		covered := "-"
+		if aggregation == recommend.AggregateCoverage {
+			covered = strconv.Itoa(r.Covered)
+		}
+		mean := 0.0
+		if r.Neighbors > 0 {
+			mean = r.Sum / float64(r.Neighbors)
+		}
+		table.Append([]string{r.RecipeID, titles[r.RecipeID], fmt.Sprintf("%.2f", mean), covered})
+	}
+	table.Render()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/julienrbrt/ut_research_project/recommend"
+	"github.com/julienrbrt/ut_research_project/util"
+	"github.com/olekukonko/tablewriter"
+)
+
+//demandCommand forecasts the portions of a recipe ordered by the neighbors of a cook
+func demandCommand(arguments []string) {
+	flags := flag.NewFlagSet("vinaigrette demand", flag.ExitOnError)
+
+	//get options
+	defaults := recommend.DefaultDemandConfig()
+	horizon := flags.Float64("horizon", defaults.HorizonDays, "days of the forecast, 1 for the orders of tomorrow")
+	threshold := flags.Float64("threshold", defaults.Threshold, "predicted rating at which a neighbor ordering a meal chooses the recipe one time out of two")
+	modelsPath := flags.String("models", "", "JSON file configuring the collaborative filtering models (default models when empty)")
+	modelName := flags.String("model", "SVD", "name of the model predicting the ratings (ranking and implicit models are rejected)")
+	travel := flags.String("travel", "", "neighbors are reached in maxDistance minutes on the road graph by walk or cycle instead of a km radius")
+	osmPath := flags.String("osm", "data/roads.osm", "OpenStreetMap extract of the roads used with -travel")
+	top := flags.Int("top", 10, "number of neighbors the most likely to order to print")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: vinaigrette demand [options] cookID recipeID maxDistance\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(arguments)
+
+	//get arguments
+	args := flags.Args()
+	if len(args) < 3 {
+		fmt.Printf("Error: argument(s) missing, only received %d\n", len(args))
+		flags.Usage()
+		os.Exit(1)
+	}
+	cookID, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Printf("Error: cookID must be an integer: %v\n", err)
+		os.Exit(1)
+	}
+	recipeID := args[1]
+	maxDistance, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		fmt.Printf("Error: maxDistance must be a number: %v\n", err)
+		os.Exit(1)
+	}
+
+	//load model
+	registry := recommend.DefaultRegistry()
+	if *modelsPath != "" {
+		registry, err = recommend.LoadRegistry(*modelsPath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+	models, err := registry.Build(*modelName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	//the threshold is a rating, scores of ranking and implicit models are not comparable to it
+	for _, c := range registry.Models {
+		if c.Name == *modelName && !c.PredictsRatings() {
+			fmt.Printf("Error: model %s does not predict ratings, the demand needs a rating model (e.g. SVD)\n", c.Name)
+			os.Exit(1)
+		}
+	}
+
+	//load datasets
+	log.Printf("Loading datasets...\n")
+	users := util.LoadCSV("data/users.csv")
+	orders := util.LoadCSV("data/orders.csv")
+
+	neighborhood, unit, err := newNeighborhood(*travel, *osmPath, maxDistance, users)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	config := defaults
+	config.HorizonDays = *horizon
+	config.Threshold = *threshold
+	forecaster, err := recommend.NewDemandForecaster(models[0].Model, orders, config)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	demand := forecaster.Forecast(recipeID, neighborhood.Neighbors(cookID))
+
+	fmt.Printf("Recipe %s cooked by user %d for %.0f day(s): %.2f ± %.2f portions expected from %d neighbors in %.0f %s (%.0f%%-%.0f%% interval: %d to %d)\n",
+		recipeID, cookID, config.HorizonDays, demand.Expected, demand.StdDev, demand.Neighbors, maxDistance, unit,
+		config.Low*100, config.High*100, demand.Low, demand.High)
+
+	//print table
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Neighbor", "Predicted Rating", "Order Probability"})
+	for i, n := range demand.PerNeighbor {
+		if i >= *top {
+			break
+		}
+		table.Append([]string{n.UserID, fmt.Sprintf("%.2f", n.Rating), fmt.Sprintf("%.4f", n.Probability)})
+	}
+	table.Render()
+}
@@ -1,45 +1,127 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-gota/gota/dataframe"
 	"github.com/julienrbrt/ut_research_project/recommend"
 	"github.com/julienrbrt/ut_research_project/util"
 )
 
+func main() {
+	//subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "search":
+			searchCommand(os.Args[2:])
+			return
+		case "plan":
+			planCommand(os.Args[2:])
+			return
+		case "shopping":
+			shoppingCommand(os.Args[2:])
+			return
+		case "demand":
+			demandCommand(os.Args[2:])
+			return
+		case "cook":
+			cookCommand(os.Args[2:])
+			return
+		case "sweep":
+			sweepCommand(os.Args[2:])
+			return
+		}
+	}
+
+	recommendCommand(os.Args[1:])
+}
+
+//recommendCommand recommends recipes to a user and compares the models
 //tool arguments
 //userID to which user to get recommendations
 //nbRecipes is the number of recipes to recommend
-//maxDistance define the maximal distance for which users are considered neighbors
-func main() {
+//maxDistance define the maximal distance (km, or minutes with -travel) for which users are considered neighbors
+func recommendCommand(arguments []string) {
+	flags := flag.NewFlagSet("vinaigrette", flag.ExitOnError)
+
+	//get options
+	splitMode := flags.String("split", "random", "evaluation split of the orders: random, temporal or loo (leave last one out per user)")
+	testRatio := flags.Float64("test-ratio", 0.2, "ratio of orders used for testing with the random split")
+	cutoff := flags.String("cutoff", "", "date (YYYY-MM-DD) from which orders are used for testing with the temporal split")
+	modelsPath := flags.String("models", "", "JSON file configuring the collaborative filtering models (default models when empty)")
+	modelNames := flags.String("model", "", "comma separated names of the models to use (enabled models when empty)")
+	implicit := flags.Bool("implicit", false, "train the collaborative filtering models on order frequency instead of ratings")
+	halfLife := flags.Float64("half-life", 0, "days after which the confidence of an implicit order is halved (no decay when 0)")
+	travel := flags.String("travel", "", "neighbors are reached in maxDistance minutes on the road graph by walk or cycle instead of a km radius")
+	osmPath := flags.String("osm", "data/roads.osm", "OpenStreetMap extract of the roads used with -travel")
+	diets := flags.String("diet", "", "comma separated diets of the user (e.g. vegetarian,glutenfree), added to its diet_ columns")
+	allergens := flags.String("allergens", "", "comma separated allergens of the user (e.g. nuts,lactose), added to its allergen_ columns")
+	allergenMapPath := flags.String("allergen-map", "", "JSON file mapping allergens to ingredients and diets to allergens (default mapping when empty)")
+	maxTime := flags.Int("max-time", 0, "maximum total time of the recommended recipes in minutes (no limit when 0)")
+	at := flags.String("at", "", "date and time (YYYY-MM-DD HH:MM) of the request, quick recipes are preferred on weekday evenings (the time is ignored when empty)")
+	people := flags.Int("people", 0, "number of people to cook for (unknown when 0)")
+	kernel := flags.String("sellability-kernel", "uniform", "decay of the weight of the neighbors in the sellability with their distance: uniform, linear, gaussian or step")
+	kernelRadius := flags.Float64("sellability-radius", 0, "radius in km of the sellability kernel (maxDistance when 0, required with -travel and a non uniform kernel)")
+	lambda := flags.Float64("lambda", 1, "trade-off between the predicted rating (1) and the appeal of the recipes to the neighbors (towards 0) in the ranking")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: vinaigrette [options] userID nbRecipes maxDistance\n       vinaigrette search [options]\n       vinaigrette plan [options] userID\n       vinaigrette shopping [options] recipeID[:servings]...\n       vinaigrette demand [options] cookID recipeID maxDistance\n       vinaigrette cook [options] cookID nbRecipes maxDistance\n       vinaigrette sweep [options]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(arguments)
+
 	//get arguments
-	args := os.Args
-	if len(args) < 4 {
-		fmt.Printf("Error: argument(s) missing, only received %d\nUsage: vinaigrette userID nbRecipes maxDistance\n", len(args))
+	args := flags.Args()
+	if len(args) < 3 {
+		fmt.Printf("Error: argument(s) missing, only received %d\n", len(args))
+		flags.Usage()
 		os.Exit(1)
 	}
 
 	//set user ID
-	userID, err := strconv.Atoi(args[1])
+	userID, err := strconv.Atoi(args[0])
 	if err != nil {
 		fmt.Printf("Error: userID must be an integer: %v\n", err)
 	}
 
 	//number recommendation
-	nbRecipes, err := strconv.Atoi(args[2])
+	nbRecipes, err := strconv.Atoi(args[1])
 	if err != nil {
 		fmt.Printf("Error: nbRecipes must be an integer: %v\n", err)
 	}
 
 	//set distance
-	maxDistance, err := strconv.ParseFloat(args[3], 64)
+	maxDistance, err := strconv.ParseFloat(args[2], 64)
 	if err != nil {
 		fmt.Printf("Error: maxDistance must be an integer: %v\n", err)
 	}
 
+	//set evaluation split
+	split, err := newSplitter(*splitMode, *testRatio, *cutoff)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	//load collaborative filtering models
+	registry := recommend.DefaultRegistry()
+	if *modelsPath != "" {
+		registry, err = recommend.LoadRegistry(*modelsPath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	models, err := registry.ForFeedback(*implicit).Build(splitList(*modelNames)...)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
 	//load datasets
 	log.Printf("Loading datasets...\n")
 	users := util.LoadCSV("data/users.csv")
@@ -47,18 +129,155 @@ func main() {
 	recipes := util.LoadCSV("data/recipes.csv")
 
 	//keep only neighboring users
-	neighborsUsers := recommend.UsersCloseByXKm(userID, maxDistance, users)
-	fmt.Printf("There is %d neighboring users from user %d in a %.0f km radius\n", neighborsUsers.Nrow(), userID, maxDistance)
+	neighborhood, unit, err := newNeighborhood(*travel, *osmPath, maxDistance, users)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//dietary restrictions and allergens are never recommended
+	filter, err := restrictionsFilter(userID, *diets, *allergens, *allergenMapPath, users, recipes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//context of the request, the meal is only taken into account when its time is given
+	context := recommend.Context{MaxTime: *maxTime, People: *people}
+	if *at != "" {
+		context.Time, err = time.ParseInLocation("2006-01-02 15:04", *at, time.Local)
+		if err != nil {
+			fmt.Printf("Error: at must be formatted as YYYY-MM-DD HH:MM: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	timeFilter, err := context.Filter(recipes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	filter = recommend.AllFilters(filter, timeFilter)
+	reranker, err := context.Reranker(recommend.DefaultContextConfig(), recipes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if !context.Time.IsZero() {
+		fmt.Printf("Recommending for %s on %s\n", context.Meal(), context.Time.Format("Monday 15:04"))
+	}
+
+	neighborsUsers := neighborhood.Neighbors(userID)
+	fmt.Printf("There is %d neighboring users from user %d in %.0f %s\n", neighborsUsers.Nrow(), userID, maxDistance, unit)
+
+	//closer neighbors are more likely to pick up a meal
+	sellabilityKernel, err := recommend.ParseKernel(*kernel)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	radius := *kernelRadius
+	if radius == 0 {
+		//maxDistance is in minutes with -travel, not in km
+		if *travel != "" && sellabilityKernel != recommend.UniformKernel {
+			fmt.Printf("Error: -sellability-radius in km is required with -travel and a %s kernel\n", sellabilityKernel)
+			os.Exit(1)
+		}
+		radius = maxDistance
+	}
+	weight, err := recommend.DistanceWeight(userID, sellabilityKernel, radius, users)
+	if err != nil {
+		log.Fatalln(err)
+	}
 
 	//content filtering
-	err = recommend.WithContentFiltering(userID, nbRecipes, 3, neighborsUsers, orders, recipes)
+	err = recommend.WithContentFiltering(userID, nbRecipes, 3, filter, reranker, weight, *lambda, neighborsUsers, orders, recipes)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
+	//implicit feedback from the orders frequency and recency
+	feedback := orders
+	if *implicit {
+		feedback, err = recommend.ImplicitOrders(orders, time.Duration(*halfLife*24*float64(time.Hour)))
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
 	//collaborative filtering
-	err = recommend.WithCollaborativeFiltering(userID, nbRecipes, neighborsUsers, orders, recipes)
+	err = recommend.WithCollaborativeFiltering(userID, nbRecipes, models, split, filter, reranker, weight, *lambda, neighborsUsers, feedback, recipes)
 	if err != nil {
 		log.Fatalln(err)
 	}
 }
+
+//splitList splits a comma separated list, an empty string is an empty list
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+//restrictionsFilter returns the filter of the recipes respecting the diets and allergens of a user and the ones given as options
+//mappingPath is the allergen mapping file, the default mapping is used when empty
+func restrictionsFilter(userID int, diets, allergens, mappingPath string, users, recipes dataframe.DataFrame) (recommend.RecipeFilter, error) {
+	mapping := recommend.DefaultAllergenMapping()
+	if mappingPath != "" {
+		var err error
+		mapping, err = recommend.LoadAllergenMapping(mappingPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	restrictions := recommend.UserRestrictions(userID, users).Merge(recommend.Restrictions{
+		Diets:     splitList(diets),
+		Allergens: splitList(allergens),
+	})
+	if restrictions.IsEmpty() {
+		return nil, nil
+	}
+	fmt.Printf("User %d is restricted to diets %v and excludes allergens %v\n", userID, restrictions.Diets, restrictions.Allergens)
+
+	return mapping.Filter(recipes, restrictions)
+}
+
+//newNeighborhood returns the neighborhood of the users in maxDistance km, or in maxDistance minutes on the roads of an
+//OpenStreetMap extract when travel is walk or cycle, and the unit of maxDistance
+func newNeighborhood(travel, osmPath string, maxDistance float64, users dataframe.DataFrame) (recommend.Neighborhood, string, error) {
+	switch travel {
+	case "":
+		neighborhood, err := recommend.NewRadiusNeighborhood(users, maxDistance)
+		return neighborhood, "km radius", err
+	case "walk", "cycle":
+		mode := util.Walking
+		if travel == "cycle" {
+			mode = util.Cycling
+		}
+		log.Printf("Loading road graph...\n")
+		graph, err := util.LoadOSM(osmPath, mode)
+		if err != nil {
+			return nil, "", err
+		}
+		neighborhood, err := recommend.NewTravelTimeNeighborhood(users, graph, maxDistance)
+		return neighborhood, "minutes " + mode.Name, err
+	default:
+		return nil, "", fmt.Errorf("unknown travel mode %q, must be walk or cycle", travel)
+	}
+}
+
+//newSplitter returns the evaluation split of the orders: random (with the ratio of test orders), temporal (from the cutoff
+//date formatted as YYYY-MM-DD) or loo
+func newSplitter(mode string, testRatio float64, cutoff string) (recommend.Splitter, error) {
+	switch mode {
+	case "random":
+		return recommend.RandomSplit(testRatio), nil
+	case "temporal":
+		date, err := time.Parse("2006-01-02", cutoff)
+		if err != nil {
+			return nil, fmt.Errorf("cutoff must be a date formatted as YYYY-MM-DD: %v", err)
+		}
+		return recommend.TemporalSplit(date), nil
+	case "loo":
+		return recommend.LeaveLastOneOutSplit(), nil
+	default:
+		return nil, fmt.Errorf("unknown split %q, must be random, temporal or loo", mode)
+	}
+}
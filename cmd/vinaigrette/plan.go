@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/julienrbrt/ut_research_project/plan"
+	"github.com/julienrbrt/ut_research_project/recipe"
+	"github.com/julienrbrt/ut_research_project/recommend"
+	"github.com/julienrbrt/ut_research_project/shopping"
+	"github.com/julienrbrt/ut_research_project/util"
+	"github.com/olekukonko/tablewriter"
+)
+
+//planCommand plans the dinners of a user from the predicted ratings of a collaborative filtering model
+func planCommand(arguments []string) {
+	flags := flag.NewFlagSet("vinaigrette plan", flag.ExitOnError)
+
+	//get options
+	days := flags.Int("days", 7, "number of dinners to plan")
+	budget := flags.Int("budget", 0, "total cooking time of the dinners in minutes (no budget when 0)")
+	maxTagRepeat := flags.Int("max-tag-repeat", plan.DefaultConfig().MaxTagRepeat, "maximum number of dinners sharing a tag (no limit when 0)")
+	reuseBonus := flags.Float64("reuse-bonus", plan.DefaultConfig().ReuseBonus, "score gained per ingredient reused from previous dinners, in standard deviations of the predicted ratings")
+	modelsPath := flags.String("models", "", "JSON file configuring the collaborative filtering models (default models when empty)")
+	modelName := flags.String("model", "SVD", "name of the model predicting the ratings")
+	diets := flags.String("diet", "", "comma separated diets of the user (e.g. vegetarian,glutenfree), added to its diet_ columns")
+	allergens := flags.String("allergens", "", "comma separated allergens of the user (e.g. nuts,lactose), added to its allergen_ columns")
+	allergenMapPath := flags.String("allergen-map", "", "JSON file mapping allergens to ingredients and diets to allergens (default mapping when empty)")
+	withShopping := flags.Bool("shopping", false, "print the shopping list of the planned dinners")
+	servings := flags.Int("servings", shopping.DefaultServings, "number of servings of the dinners in the shopping list")
+	ingredientsPath := flags.String("ingredients", "data/ingredients.csv", "CSV file of the ingredient lines of the recipes used with -shopping")
+	minNutrition := make(map[string]*float64)
+	maxNutrition := make(map[string]*float64)
+	for _, n := range recipe.Nutrients {
+		minNutrition[n] = flags.Float64("min-"+n, 0, fmt.Sprintf("minimum mean %s per dinner (not enforced when 0)", n))
+		maxNutrition[n] = flags.Float64("max-"+n, 0, fmt.Sprintf("maximum mean %s per dinner (not enforced when 0)", n))
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: vinaigrette plan [options] userID\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(arguments)
+
+	//get arguments
+	args := flags.Args()
+	if len(args) < 1 {
+		fmt.Printf("Error: argument missing, userID is required\n")
+		flags.Usage()
+		os.Exit(1)
+	}
+	userID, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Printf("Error: userID must be an integer: %v\n", err)
+		os.Exit(1)
+	}
+
+	//load model
+	registry := recommend.DefaultRegistry()
+	if *modelsPath != "" {
+		registry, err = recommend.LoadRegistry(*modelsPath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+	models, err := registry.Build(*modelName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//load datasets
+	log.Printf("Loading datasets...\n")
+	users := util.LoadCSV("data/users.csv")
+	orders := util.LoadCSV("data/orders.csv")
+	recipes := util.LoadCSV("data/recipes.csv")
+
+	filter, err := restrictionsFilter(userID, *diets, *allergens, *allergenMapPath, users, recipes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//plan the recipes with the best predicted ratings
+	config := plan.DefaultConfig()
+	config.Days = *days
+	config.MaxTotalTime = *budget
+	config.MaxTagRepeat = *maxTagRepeat
+	config.ReuseBonus = *reuseBonus
+	config.Nutrition = make(map[string]plan.Bounds)
+	for _, n := range recipe.Nutrients {
+		if *minNutrition[n] > 0 || *maxNutrition[n] > 0 {
+			config.Nutrition["nutrition_"+n] = plan.Bounds{Min: *minNutrition[n], Max: *maxNutrition[n]}
+		}
+	}
+
+	scores := recommend.UserScores(userID, models[0].Model, orders, recipes, filter)
+	mealPlan, err := plan.Generate(scores, recipes, config)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//print table
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Day", "Recipe", "Title", "Predicted Rating", "Minutes", "Reused Ingredients"})
+	for i, d := range mealPlan.Days {
+		table.Append([]string{
+			strconv.Itoa(i + 1),
+			d.RecipeID,
+			d.Title,
+			fmt.Sprintf("%.2f", d.Score),
+			strconv.Itoa(d.Minutes),
+			strings.Join(d.Reused, " "),
+		})
+	}
+	table.SetFooter([]string{"", "", "", "Total", strconv.Itoa(mealPlan.TotalTime), ""})
+	table.Render()
+	for _, n := range recipe.Nutrients {
+		if v, ok := mealPlan.Nutrition["nutrition_"+n]; ok {
+			fmt.Printf("Mean %s per dinner: %.1f\n", n, v)
+		}
+	}
+
+	//shopping list of the week
+	if *withShopping {
+		var selections []shopping.Selection
+		for _, d := range mealPlan.Days {
+			selections = append(selections, shopping.Selection{RecipeID: d.RecipeID, Servings: *servings})
+		}
+		fmt.Println()
+		if err := writeShoppingList(os.Stdout, selections, *ingredientsPath, "text"); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
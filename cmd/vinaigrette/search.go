@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/julienrbrt/ut_research_project/recommend"
+	"github.com/julienrbrt/ut_research_project/util"
+	"github.com/olekukonko/tablewriter"
+)
+
+//searchCommand searches the best hyperparameters of the models of a parameter space file
+//the best parameters per metric are written as a models configuration usable with -models
+func searchCommand(arguments []string) {
+	flags := flag.NewFlagSet("vinaigrette search", flag.ExitOnError)
+
+	//get options
+	spacePath := flags.String("space", "config/search.json", "JSON file defining the parameter space of the models")
+	outPath := flags.String("out", "config/best_models.json", "JSON file in which the best parameters per metric are written")
+	method := flags.String("method", "", "search method: grid, random or halving (parameter space file value when not set)")
+	cv := flags.String("cv", "", "cross validation: kfold or temporal (parameter space file value when not set)")
+	folds := flags.Int("folds", 0, "number of cross validation folds (parameter space file value when not set)")
+	trials := flags.Int("trials", 0, "number of random search or successive halving candidates (parameter space file value when not set)")
+	metric := flags.String("metric", "", "metric optimized by early stopping and successive halving: precision, recall or rmse (parameter space file value when not set)")
+	patience := flags.Int("patience", 0, "trials without improvement before stopping the random search (parameter space file value when not set)")
+	trialLog := flags.String("trial-log", "", "JSON lines file logging the trials, an interrupted search resumes from it (parameter space file value when not set)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: vinaigrette search [options]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(arguments)
+
+	//load parameter space
+	file, err := recommend.LoadSearchFile(*spacePath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//override parameter space file settings with the options set, even to a zero value (e.g. -patience 0)
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	if set["method"] {
+		file.Method = *method
+	}
+	if set["cv"] {
+		file.CV = *cv
+	}
+	if set["folds"] {
+		file.Folds = *folds
+	}
+	if set["trials"] {
+		file.Trials = *trials
+	}
+	if set["metric"] {
+		file.Metric = *metric
+	}
+	if set["patience"] {
+		file.Patience = *patience
+	}
+	if set["trial-log"] {
+		file.TrialLog = *trialLog
+	}
+
+	//load datasets
+	log.Printf("Loading datasets...\n")
+	orders := util.LoadCSV("data/orders.csv")
+
+	results, err := recommend.SearchAll(file, orders)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//print table
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Model", "Metric", "Score", "Params"})
+	for _, r := range results {
+		table.Append([]string{r.Name, r.Metric, fmt.Sprintf("%.5f", r.Score), fmt.Sprintf("%v", r.Params)})
+	}
+	table.Render()
+
+	//save best parameters
+	if err := recommend.WriteRegistry(recommend.BestParamsRegistry(results), *outPath); err != nil {
+		log.Fatalln(err)
+	}
+}
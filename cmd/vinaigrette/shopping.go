@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/julienrbrt/ut_research_project/shopping"
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+//shoppingCommand writes the shopping list of recipes
+//recipes are given as recipeID or recipeID:servings
+func shoppingCommand(arguments []string) {
+	flags := flag.NewFlagSet("vinaigrette shopping", flag.ExitOnError)
+
+	//get options
+	servings := flags.Int("servings", shopping.DefaultServings, "number of servings of the recipes given without servings")
+	format := flags.String("format", "text", "format of the shopping list: text or json")
+	ingredientsPath := flags.String("ingredients", "data/ingredients.csv", "CSV file of the ingredient lines of the recipes")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: vinaigrette shopping [options] recipeID[:servings]...\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(arguments)
+
+	if flags.NArg() == 0 {
+		fmt.Printf("Error: argument missing, at least one recipeID is required\n")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	var selections []shopping.Selection
+	for _, arg := range flags.Args() {
+		s := shopping.Selection{RecipeID: arg, Servings: *servings}
+		if i := strings.Index(arg, ":"); i >= 0 {
+			n, err := strconv.Atoi(arg[i+1:])
+			if err != nil {
+				fmt.Printf("Error: servings of %s must be an integer: %v\n", arg, err)
+				os.Exit(1)
+			}
+			s = shopping.Selection{RecipeID: arg[:i], Servings: n}
+		}
+		selections = append(selections, s)
+	}
+
+	if err := writeShoppingList(os.Stdout, selections, *ingredientsPath, *format); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+//writeShoppingList writes the shopping list of the selected recipes as text or json
+func writeShoppingList(w io.Writer, selections []shopping.Selection, ingredientsPath, format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q, must be text or json", format)
+	}
+
+	book, err := shopping.NewBook(util.LoadCSV(ingredientsPath))
+	if err != nil {
+		return err
+	}
+	list, err := book.List(selections, shopping.DefaultCategories())
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		return list.WriteJSON(w)
+	}
+	return list.WriteText(w)
+}
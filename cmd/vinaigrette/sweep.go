@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/go-gota/gota/series"
+	"github.com/julienrbrt/ut_research_project/recommend"
+	"github.com/julienrbrt/ut_research_project/util"
+	"github.com/olekukonko/tablewriter"
+)
+
+//sweepCommand measures the precision, recall and sellability of the models for a sample of users and several radii
+//the records are written in a tidy CSV and their mean per model and radius is printed with its confidence interval
+func sweepCommand(arguments []string) {
+	flags := flag.NewFlagSet("vinaigrette sweep", flag.ExitOnError)
+
+	//get options
+	radiiList := flags.String("radii", "0.5,1,2,5,10", "comma separated neighbors radii in km")
+	nbUsers := flags.Int("users", 100, "number of users sampled (all users when 0)")
+	seed := flags.Int64("seed", 1, "seed of the sample of users")
+	nbRecipes := flags.Int("nb-recipes", 10, "number of recipes recommended to each user")
+	nbTags := flags.Int("tags", 3, "number of preferred tags of content filtering (content filtering is not measured when 0)")
+	splitMode := flags.String("split", "random", "evaluation split of the orders: random, temporal or loo (leave last one out per user)")
+	testRatio := flags.Float64("test-ratio", 0.2, "ratio of orders used for testing with the random split")
+	cutoff := flags.String("cutoff", "", "date (YYYY-MM-DD) from which orders are used for testing with the temporal split")
+	modelsPath := flags.String("models", "", "JSON file configuring the collaborative filtering models (default models when empty)")
+	modelNames := flags.String("model", "", "comma separated names of the models to use (enabled models when empty)")
+	implicit := flags.Bool("implicit", false, "train the collaborative filtering models on order frequency instead of ratings")
+	halfLife := flags.Float64("half-life", 0, "days after which the confidence of an implicit order is halved (no decay when 0)")
+	kernel := flags.String("sellability-kernel", "uniform", "decay of the weight of the neighbors in the sellability with their distance: uniform, linear, gaussian or step")
+	workers := flags.Int("workers", 0, "number of users measured in parallel (number of CPUs when 0)")
+	confidence := flags.Float64("confidence", 0.95, "level of the confidence intervals of the summary")
+	outPath := flags.String("out", "data/sweep.csv", "CSV file in which the records (user, model, radius, metric, value) are written")
+	summaryPath := flags.String("summary", "", "CSV file in which the summary is written (only printed when empty)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: vinaigrette sweep [options]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(arguments)
+
+	config := recommend.SweepConfig{Users: *nbUsers, Seed: *seed, NbRecipes: *nbRecipes, NbTags: *nbTags, Workers: *workers}
+	for _, r := range splitList(*radiiList) {
+		radius, err := strconv.ParseFloat(r, 64)
+		if err != nil {
+			fmt.Printf("Error: radius %s must be a number: %v\n", r, err)
+			os.Exit(1)
+		}
+		config.Radii = append(config.Radii, radius)
+	}
+	var err error
+	config.Kernel, err = recommend.ParseKernel(*kernel)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	split, err := newSplitter(*splitMode, *testRatio, *cutoff)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	//load collaborative filtering models
+	registry := recommend.DefaultRegistry()
+	if *modelsPath != "" {
+		registry, err = recommend.LoadRegistry(*modelsPath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+	models, err := registry.ForFeedback(*implicit).Build(splitList(*modelNames)...)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//load datasets
+	log.Printf("Loading datasets...\n")
+	users := util.LoadCSV("data/users.csv")
+	orders := util.LoadCSV("data/orders.csv")
+	recipes := util.LoadCSV("data/recipes.csv")
+
+	//implicit feedback from the orders frequency and recency
+	feedback := orders
+	if *implicit {
+		feedback, err = recommend.ImplicitOrders(orders, time.Duration(*halfLife*24*float64(time.Hour)))
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	result, err := recommend.Sweep(models, split, config, users, orders, feedback, recipes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := util.WriteCSV(result.DataFrame(), *outPath); err != nil {
+		log.Fatalln(err)
+	}
+
+	summaries := result.Summary(*confidence)
+	if *summaryPath != "" {
+		if err := util.WriteCSV(summaryDataFrame(summaries), *summaryPath); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	//print table
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Model", "Radius", "Metric", "Users", "Mean", "Std Dev", fmt.Sprintf("%.0f%% CI", *confidence*100)})
+	for _, s := range summaries {
+		table.Append([]string{
+			s.Model,
+			strconv.FormatFloat(s.Radius, 'f', -1, 64),
+			s.Metric,
+			strconv.Itoa(s.N),
+			fmt.Sprintf("%.5f", s.Mean),
+			fmt.Sprintf("%.5f", s.StdDev),
+			fmt.Sprintf("[%.5f, %.5f]", s.Low, s.High),
+		})
+	}
+	table.Render()
+}
+
+//summaryDataFrame returns the summaries as a dataframe with model, radius, metric, n, mean, sd, low and high columns
+func summaryDataFrame(summaries []recommend.SweepSummary) dataframe.DataFrame {
+	models := make([]string, len(summaries))
+	radii := make([]float64, len(summaries))
+	metrics := make([]string, len(summaries))
+	n := make([]int, len(summaries))
+	means := make([]float64, len(summaries))
+	sd := make([]float64, len(summaries))
+	low := make([]float64, len(summaries))
+	high := make([]float64, len(summaries))
+	for i, s := range summaries {
+		models[i], radii[i], metrics[i], n[i] = s.Model, s.Radius, s.Metric, s.N
+		means[i], sd[i], low[i], high[i] = s.Mean, s.StdDev, s.Low, s.High
+	}
+
+	return dataframe.New(
+		series.New(models, series.String, "model"),
+		series.New(radii, series.Float, "radius"),
+		series.New(metrics, series.String, "metric"),
+		series.New(n, series.Int, "n"),
+		series.New(means, series.Float, "mean"),
+		series.New(sd, series.Float, "sd"),
+		series.New(low, series.Float, "low"),
+		series.New(high, series.Float, "high"),
+	)
+}
@@ -0,0 +1,91 @@
+package generate
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/julienrbrt/ut_research_project/geo"
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+//CommunityConfig contains the parameters of the taste communities
+//a community has a location and a taste shared by the users living around it, like a student neighbourhood or a region
+type CommunityConfig struct {
+	//Count is the number of communities, users have independent tastes when 0
+	Count int `json:"count"`
+	//RadiusKm is the distance at which the influence of a community decreases to 60%
+	RadiusKm float64 `json:"radiusKm"`
+	//Correlation in [0, 1] is the share of the taste of a user at the center of a community coming from the community
+	Correlation float64 `json:"correlation"`
+}
+
+//Validate verifies the parameters
+func (c CommunityConfig) Validate() error {
+	switch {
+	case c.Count < 0:
+		return errors.New("number of communities must not be negative")
+	case c.Count > 0 && c.RadiusKm <= 0:
+		return errors.New("communities radius must be positive")
+	case c.Correlation < 0 || c.Correlation > 1:
+		return errors.New("communities correlation must be between 0 and 1")
+	}
+
+	return nil
+}
+
+//community is a taste community
+type community struct {
+	location util.Geolocation
+	taste    []float64
+}
+
+//communities contains the taste communities of the generation
+type communities struct {
+	config      CommunityConfig
+	communities []community
+}
+
+//newCommunities draws the communities, located with the users location sampler so they are where users live
+func newCommunities(config CommunityConfig, locations LocationSampler, dimensions int, rng *rand.Rand) (*communities, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := &communities{config: config}
+	for i := 0; i < config.Count; i++ {
+		_, location, err := locations.Sample(rng)
+		if err != nil {
+			return nil, err
+		}
+		c.communities = append(c.communities, community{location: location, taste: gaussianVector(rng, dimensions)})
+	}
+
+	return c, nil
+}
+
+//taste mixes the individual taste of a user with the taste of the closest community
+//the user belongs to the community with a strength s = exp(-d² / 2r²) and its taste is √(ρs) · community + √(1 - ρs) · individual
+//so tastes keep a unit variance and two users at the center of a community have a taste correlation of ρ
+//it returns the taste and the closest community (from 1), 0 without communities
+func (c *communities) taste(location util.Geolocation, individual []float64) ([]float64, int) {
+	if len(c.communities) == 0 || c.config.Correlation == 0 {
+		return individual, 0
+	}
+
+	//closest community
+	closest, distance := 0, math.Inf(1)
+	for i, com := range c.communities {
+		if d := geo.Haversine(location, com.location); d < distance {
+			closest, distance = i, d
+		}
+	}
+
+	share := c.config.Correlation * math.Exp(-distance*distance/(2*c.config.RadiusKm*c.config.RadiusKm))
+	taste := make([]float64, len(individual))
+	for d := range taste {
+		taste[d] = math.Sqrt(share)*c.communities[closest].taste[d] + math.Sqrt(1-share)*individual[d]
+	}
+
+	return taste, closest + 1
+}
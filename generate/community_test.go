@@ -0,0 +1,90 @@
+package generate
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+//fixedSampler samples its locations in turn
+type fixedSampler struct {
+	locations []util.Geolocation
+	next      int
+}
+
+func (s *fixedSampler) Sample(rng *rand.Rand) (string, util.Geolocation, error) {
+	l := s.locations[s.next%len(s.locations)]
+	s.next++
+	return "", l, nil
+}
+
+//meanTasteCorrelation returns the mean correlation of the tastes of users from two groups
+func meanTasteCorrelation(a, b [][]float64) float64 {
+	sum, count := 0.0, 0
+	for i := range a {
+		for j := range b {
+			if &a[i][0] == &b[j][0] {
+				continue
+			}
+			sum += dot(a[i], b[j]) / float64(len(a[i]))
+			count++
+		}
+	}
+	return sum / float64(count)
+}
+
+func TestCommunities(t *testing.T) {
+	enschede := util.Geolocation{Latitude: 52.2215, Longitude: 6.8937}
+	amsterdam := util.Geolocation{Latitude: 52.3676, Longitude: 4.9041}
+	dimensions := 32
+
+	for _, correlation := range []float64{0, 0.8} {
+		rng := rand.New(rand.NewSource(42))
+		config := CommunityConfig{Count: 2, RadiusKm: 2, Correlation: correlation}
+		c, err := newCommunities(config, &fixedSampler{locations: []util.Geolocation{enschede, amsterdam}}, dimensions, rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//users living at the center of the communities
+		var inEnschede, inAmsterdam [][]float64
+		for i := 0; i < 100; i++ {
+			taste, community := c.taste(enschede, gaussianVector(rng, dimensions))
+			if correlation > 0 && community != 1 {
+				t.Errorf("Community is incorrect, got '%d', want '1'", community)
+			}
+			inEnschede = append(inEnschede, taste)
+
+			taste, _ = c.taste(amsterdam, gaussianVector(rng, dimensions))
+			inAmsterdam = append(inAmsterdam, taste)
+		}
+
+		within := meanTasteCorrelation(inEnschede, inEnschede)
+		across := meanTasteCorrelation(inEnschede, inAmsterdam)
+		if correlation > 0 && (within < correlation-0.2 || within-across < 0.4) {
+			t.Errorf("Taste correlation is incorrect, got '%f' within a community and '%f' across, want about '%f' within", within, across, correlation)
+		}
+		if correlation == 0 && (within > 0.1 || within < -0.1) {
+			t.Errorf("Taste correlation without communities is incorrect, got '%f', want '0'", within)
+		}
+	}
+
+	//far away users are not influenced
+	rng := rand.New(rand.NewSource(42))
+	c, err := newCommunities(CommunityConfig{Count: 1, RadiusKm: 2, Correlation: 1}, &fixedSampler{locations: []util.Geolocation{enschede}}, dimensions, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	individual := gaussianVector(rng, dimensions)
+	taste, _ := c.taste(amsterdam, individual)
+	for d := range taste {
+		if taste[d]-individual[d] > 1e-9 || individual[d]-taste[d] > 1e-9 {
+			t.Fatalf("Taste far from the communities is incorrect, got '%v', want '%v'", taste, individual)
+		}
+	}
+
+	if err := (CommunityConfig{Count: 2, RadiusKm: 0, Correlation: 0.5}).Validate(); err == nil {
+		t.Error("Expected an error for communities without radius")
+	}
+}
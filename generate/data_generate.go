@@ -6,10 +6,10 @@ import (
 	"math/rand"
 	"regexp"
 	"strconv"
+	"time"
 
-	"github.com/brianvoe/gofakeit/v5"
+	"github.com/brianvoe/gofakeit/v5/data"
 	"github.com/go-gota/gota/dataframe"
-	"github.com/go-gota/gota/series"
 	"github.com/julienrbrt/ut_research_project/util"
 )
 
@@ -17,11 +17,14 @@ import (
 type User struct {
 	ID              int
 	Name            string
+	Postcode        string
 	Latitude        float64
 	Longitude       float64
+	Community       int
 	FoodPreferences []string
 	OrdersHistory   []int
 	OrdersRating    []int
+	OrdersDate      []time.Time
 }
 
 //GeneratedUsers contains a list of generated users
@@ -35,84 +38,99 @@ const maxLatitudeNL = 53.5842
 const minLongitudeNL = 3.987
 const maxLongitudeNL = 7.8929
 
+//ordersPeriod is the period before the end of the orders in which orders are placed
+const ordersPeriod = 365 * 24 * time.Hour
+
+//defaultOrdersEnd is the date of the last possible order when none is configured
+var defaultOrdersEnd = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+//Config contains the parameters of the users and orders generation
+//the same configuration and recipes always generate the same users and orders
+type Config struct {
+	//Users is the number of users to generate
+	Users int
+	//Seed initializes the random source of the generation
+	Seed int64
+	//OrdersEnd is the date of the last possible order, orders are placed in the year before (1 January 2021 when zero)
+	OrdersEnd time.Time
+	//Locations samples the users location, in the bounding box of the Netherlands when nil
+	Locations LocationSampler
+	//Taste contains the parameters of the orders generation
+	Taste TasteConfig
+	//Communities contains the parameters of the spatially clustered tastes, users tastes are independent by default
+	Communities CommunityConfig
+}
+
+//DefaultConfig returns the configuration generating n users
+func DefaultConfig(n int) Config {
+	return Config{
+		Users:     n,
+		Seed:      1,
+		OrdersEnd: defaultOrdersEnd,
+		Locations: BoundingBoxSampler{},
+		Taste:     DefaultTasteConfig(),
+	}
+}
+
 //generateUsers generates user data with recipes
-func generateUsers(n int, recipes dataframe.DataFrame) (GeneratedUsers, error) {
+func generateUsers(recipes dataframe.DataFrame, config Config) (GeneratedUsers, error) {
 	var users GeneratedUsers
-	var err error
 
-	//build tags list
-	reg := regexp.MustCompile("tag_")
-	tags := make(map[int]string)
-	for i, t := range recipes.Names() {
-		if reg.MatchString(t) {
-			tags[i] = t
-		}
+	//every random value comes from the seeded source
+	rng := rand.New(rand.NewSource(config.Seed))
+
+	//orders are placed in the period preceding the end of the orders
+	ordersEnd := config.OrdersEnd
+	if ordersEnd.IsZero() {
+		ordersEnd = defaultOrdersEnd
+	}
+	ordersStart := ordersEnd.Add(-ordersPeriod)
+
+	locations := config.Locations
+	if locations == nil {
+		locations = BoundingBoxSampler{}
+	}
+
+	//latent taste of the tags and ingredients
+	taste, err := newTasteModel(recipes, config.Taste, rng)
+	if err != nil {
+		return GeneratedUsers{}, err
+	}
+
+	//communities sharing a taste
+	communities, err := newCommunities(config.Communities, locations, config.Taste.Dimensions, rng)
+	if err != nil {
+		return GeneratedUsers{}, err
 	}
 
-	for i := 0; i < n; i++ {
-		log.Printf("Generating user %d / %d...\n", i+1, n)
+	firstNames, lastNames := data.Person["first"], data.Person["last"]
+
+	for i := 0; i < config.Users; i++ {
+		log.Printf("Generating user %d / %d...\n", i+1, config.Users)
 
 		user := User{
 			ID:   i + 1,
-			Name: gofakeit.Name(),
+			Name: firstNames[rng.Intn(len(firstNames))] + " " + lastNames[rng.Intn(len(lastNames))],
 		}
 
-		user.Latitude, err = gofakeit.LatitudeInRange(minLatitudeNL, maxLatitudeNL)
-		if err != nil {
-			return GeneratedUsers{}, err
-		}
-		user.Longitude, err = gofakeit.LongitudeInRange(minLongitudeNL, maxLongitudeNL)
+		postcode, location, err := locations.Sample(rng)
 		if err != nil {
 			return GeneratedUsers{}, err
 		}
+		user.Postcode = postcode
+		user.Latitude = location.Latitude
+		user.Longitude = location.Longitude
 
-		//random food preferences (aka tags)
-		randomTags := rand.Perm(len(tags))
-		// assumption that a meal-sharing user will not enter more than 12 tags
-		randomNb := rand.Intn(12)
-		//get subset of tags preferences
-		for i := 0; i < randomNb; i++ {
-			if val, ok := tags[randomTags[i]]; ok {
-				user.FoodPreferences = append(user.FoodPreferences, val)
-			}
-		}
-
-		//match recipe of user food prefrences
-		var filterRecipes []dataframe.F
-		for _, f := range user.FoodPreferences {
-			filterRecipes = append(filterRecipes, dataframe.F{Colname: f, Comparator: series.Eq, Comparando: "1"})
-		}
-		matchingRecipes := recipes.Filter(filterRecipes...).Records()
-
-		//random number of orders that match food preferences
-		//assumption that a meal-sharing user will not have more than 50 orders
-		randomNb = rand.Intn(50) + 1
-
-		//add recipes matching user taste
-		for i := 0; i < randomNb; i++ {
-			//random index
-			index := rand.Intn(randomNb) + 1
-			//randomness give us a recipe out of bound, skip this iteration
-			if index >= len(matchingRecipes) {
-				continue
-			}
-
-			//generate random orderID
-			orderID, err := strconv.Atoi(matchingRecipes[index][0])
-			if err != nil {
-				continue
-			}
-
-			user.OrdersHistory = append(user.OrdersHistory, orderID)
-		}
-
-		//keep only the unique orders id
-		user.OrdersHistory = util.Unique(user.OrdersHistory)
+		//food preferences (aka tags) and orders follow the user taste, influenced by its community
+		userTaste, community := communities.taste(location, taste.userTaste(rng))
+		user.Community = community
+		user.FoodPreferences = taste.preferences(userTaste, rng)
+		user.OrdersHistory, user.OrdersRating = taste.orders(userTaste, rng)
 
-		//random rating from order history
+		//we generate order date
 		for range user.OrdersHistory {
-			//we generate order rating
-			user.OrdersRating = append(user.OrdersRating, rand.Intn(5)+1)
+			date := ordersStart.Add(time.Duration(rng.Int63n(int64(ordersPeriod))))
+			user.OrdersDate = append(user.OrdersDate, date.Truncate(time.Second))
 		}
 
 		users.Users = append(users.Users, user)
@@ -125,7 +143,7 @@ func generateUsers(n int, recipes dataframe.DataFrame) (GeneratedUsers, error) {
 func (users *GeneratedUsers) transformToUserDF(tags []string) dataframe.DataFrame {
 	log.Println("Processing...")
 
-	headers := []string{"id", "name", "latitude", "longitude"}
+	headers := []string{"id", "name", "postcode", "latitude", "longitude", "community"}
 	records := [][]string{}
 
 	//append tags to headers
@@ -136,8 +154,10 @@ func (users *GeneratedUsers) transformToUserDF(tags []string) dataframe.DataFram
 		data := []string{
 			strconv.Itoa(user.ID),
 			user.Name,
+			user.Postcode,
 			fmt.Sprintf("%f", user.Latitude),
 			fmt.Sprintf("%f", user.Longitude),
+			strconv.Itoa(user.Community),
 		}
 
 		//map of contained tags
@@ -168,7 +188,7 @@ func (users *GeneratedUsers) transformToUserDF(tags []string) dataframe.DataFram
 func (users *GeneratedUsers) transformToOrderDF() dataframe.DataFrame {
 	log.Println("Processing...")
 
-	headers := []string{"user_id", "recipe_id", "rating"}
+	headers := []string{"user_id", "recipe_id", "rating", "date"}
 	records := [][]string{}
 
 	for _, user := range users.Users {
@@ -178,6 +198,7 @@ func (users *GeneratedUsers) transformToOrderDF() dataframe.DataFrame {
 				strconv.Itoa(user.ID),
 				strconv.Itoa(user.OrdersHistory[i]),
 				strconv.Itoa(user.OrdersRating[i]),
+				user.OrdersDate[i].Format(util.DateLayout),
 			}
 
 			records = append(records, data)
@@ -190,10 +211,10 @@ func (users *GeneratedUsers) transformToOrderDF() dataframe.DataFrame {
 	return df
 }
 
-//UsersData generate N user data
-func UsersData(n int, recipes dataframe.DataFrame, csvPathUsers, csvPathOrders string) error {
+//UsersData generate user data from the configuration
+func UsersData(recipes dataframe.DataFrame, config Config, csvPathUsers, csvPathOrders string) error {
 	//generate data
-	users, err := generateUsers(n, recipes)
+	users, err := generateUsers(recipes, config)
 	if err != nil {
 		return err
 	}
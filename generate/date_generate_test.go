@@ -1,22 +1,24 @@
 package generate
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
-	"github.com/brianvoe/gofakeit/v5"
 	"github.com/julienrbrt/ut_research_project/recipe"
 	"github.com/julienrbrt/ut_research_project/util"
 )
 
 func TestGenerateUsers(t *testing.T) {
-	//set seed
-	gofakeit.Seed(42)
-
-	recipes, err := recipe.RecipesData(15, "")
+	recipes, err := recipe.RecipesData(15, "", "")
 	if err != nil {
 		panic(err)
 	}
-	users, err := generateUsers(15, recipes)
+	config := DefaultConfig(15)
+	config.Seed = 42
+	users, err := generateUsers(recipes, config)
 	if err != nil {
 		panic(err)
 	}
@@ -46,5 +48,60 @@ func TestGenerateUsers(t *testing.T) {
 		if len(util.Unique(u.OrdersHistory)) != len(u.OrdersHistory) {
 			t.Error("OrdersHistory is incorrect with mismatched length")
 		}
+		if len(u.OrdersDate) != len(u.OrdersHistory) {
+			t.Errorf("OrdersDate is incorrect, got '%d' dates, want '%d'", len(u.OrdersDate), len(u.OrdersHistory))
+		}
+	}
+}
+
+func TestUsersDataReproducible(t *testing.T) {
+	dir, err := ioutil.TempDir("", "generate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	recipes := testRecipes(100, 10, 20)
+	postcodes, err := util.LoadPostcodes("../config/postcodes_sample.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	locations, err := NewPostcodeSampler(postcodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//generate the files of a seed
+	generateFiles := func(name string, seed int64) (users, orders []byte) {
+		config := DefaultConfig(30)
+		config.Seed = seed
+		config.Locations = locations
+
+		usersPath := filepath.Join(dir, name+"_users.csv")
+		ordersPath := filepath.Join(dir, name+"_orders.csv")
+		if err := UsersData(recipes, config, usersPath, ordersPath); err != nil {
+			t.Fatal(err)
+		}
+
+		users, err := ioutil.ReadFile(usersPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orders, err = ioutil.ReadFile(ordersPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return users, orders
+	}
+
+	users, orders := generateFiles("first", 42)
+	sameUsers, sameOrders := generateFiles("second", 42)
+	if !bytes.Equal(users, sameUsers) || !bytes.Equal(orders, sameOrders) {
+		t.Error("Users and orders generated with the same seed should be identical")
+	}
+
+	otherUsers, otherOrders := generateFiles("other", 43)
+	if bytes.Equal(users, otherUsers) || bytes.Equal(orders, otherOrders) {
+		t.Error("Users and orders generated with different seeds should differ")
 	}
 }
@@ -0,0 +1,164 @@
+package generate
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/julienrbrt/ut_research_project/geo"
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+//LocationSampler samples the location of generated users
+type LocationSampler interface {
+	//Sample returns a location and its postcode, empty when unknown
+	Sample(rng *rand.Rand) (postcode string, location util.Geolocation, err error)
+}
+
+//BoundingBoxSampler samples locations uniformly in the bounding box of the Netherlands
+//the bounding box contains parts of the North Sea, Belgium and Germany
+type BoundingBoxSampler struct{}
+
+//Sample returns a random location in the bounding box
+func (BoundingBoxSampler) Sample(rng *rand.Rand) (string, util.Geolocation, error) {
+	return "", util.Geolocation{
+		Latitude:  minLatitudeNL + rng.Float64()*(maxLatitudeNL-minLatitudeNL),
+		Longitude: minLongitudeNL + rng.Float64()*(maxLongitudeNL-minLongitudeNL),
+	}, nil
+}
+
+//PostcodeSampler samples locations among the centroids of the most precise postcodes of a dataset
+//so generated users are located on land within real municipalities
+type PostcodeSampler struct {
+	postcodes []util.Postcode
+}
+
+//NewPostcodeSampler creates a sampler of the postcodes of a dataset
+func NewPostcodeSampler(postcodes *util.Postcodes) (*PostcodeSampler, error) {
+	finest := postcodes.Finest()
+	if len(finest) == 0 {
+		return nil, errors.New("postcodes dataset is empty")
+	}
+
+	return &PostcodeSampler{postcodes: finest}, nil
+}
+
+//Sample returns the postcode and location of a random postcode
+func (s *PostcodeSampler) Sample(rng *rand.Rand) (string, util.Geolocation, error) {
+	pc := s.postcodes[rng.Intn(len(s.postcodes))]
+	return pc.Code, pc.Location, nil
+}
+
+//maxJitterAttempts is the number of jitters tried before a user is located at the centroid of its area
+const maxJitterAttempts = 10
+
+//areas approximates the areas of centroids by their Voronoi cells, bounded by the distance to the closest other centroid
+//so a jittered location stays in the area of its centroid and does not drift far where there is no neighboring area (sea, border)
+type areas struct {
+	index *util.GeoIndex
+	//distance of each centroid to the closest other centroid
+	reach []float64
+}
+
+//newAreas creates the areas of centroids, identified by their position
+func newAreas(centroids []util.Geolocation) *areas {
+	a := &areas{index: util.NewGeoIndex(1), reach: make([]float64, len(centroids))}
+	for i, c := range centroids {
+		a.index.Add(i, c.Latitude, c.Longitude)
+	}
+	for i, c := range centroids {
+		a.reach[i] = math.Inf(1)
+		for _, n := range a.index.Nearest(c.Latitude, c.Longitude, 2) {
+			if n.ID != i {
+				a.reach[i] = n.Distance
+			}
+		}
+	}
+
+	return a
+}
+
+//contains returns if a location is in the area of a centroid
+func (a *areas) contains(id int, location util.Geolocation) bool {
+	nearest := a.index.Nearest(location.Latitude, location.Longitude, 1)
+	return len(nearest) == 1 && nearest[0].ID == id && nearest[0].Distance <= a.reach[id]
+}
+
+//PopulationSampler samples locations proportionally to the population of municipalities
+//a location is a random postcode of the sampled municipality, or its centroid without postcodes, moved by a random jitter
+//the jitter is sampled again when the location leaves the area of the postcode or municipality (see areas)
+type PopulationSampler struct {
+	municipalities []util.Municipality
+	//cumulative population of the municipalities
+	cumulative []float64
+	postcodes  map[string][]int
+	jitterKm   float64
+	//all postcodes of the municipalities and the areas of the postcodes and municipalities
+	all               []util.Postcode
+	postcodeAreas     *areas
+	municipalityAreas *areas
+}
+
+//NewPopulationSampler creates a sampler of the municipalities population
+//postcodes can be nil, jitterKm is the standard deviation of the distance between a user and its postcode or municipality centroid
+func NewPopulationSampler(municipalities []util.Municipality, postcodes *util.Postcodes, jitterKm float64) (*PopulationSampler, error) {
+	s := &PopulationSampler{
+		municipalities: municipalities,
+		postcodes:      make(map[string][]int),
+		jitterKm:       jitterKm,
+	}
+
+	total := 0.0
+	var centroids, postcodeCentroids []util.Geolocation
+	for _, m := range municipalities {
+		total += float64(m.Population)
+		s.cumulative = append(s.cumulative, total)
+		centroids = append(centroids, m.Location)
+		if postcodes != nil {
+			for _, pc := range postcodes.InMunicipality(m.Name) {
+				s.postcodes[m.Name] = append(s.postcodes[m.Name], len(s.all))
+				s.all = append(s.all, pc)
+				postcodeCentroids = append(postcodeCentroids, pc.Location)
+			}
+		}
+	}
+	if total == 0 {
+		return nil, errors.New("municipalities have no population")
+	}
+	if jitterKm > 0 {
+		s.municipalityAreas = newAreas(centroids)
+		s.postcodeAreas = newAreas(postcodeCentroids)
+	}
+
+	return s, nil
+}
+
+//Sample returns the location of a random inhabitant and its postcode
+func (s *PopulationSampler) Sample(rng *rand.Rand) (string, util.Geolocation, error) {
+	//municipality with a probability proportional to its population
+	r := rng.Float64() * s.cumulative[len(s.cumulative)-1]
+	id := sort.Search(len(s.cumulative), func(i int) bool { return s.cumulative[i] > r })
+	m := s.municipalities[id]
+
+	postcode, location, area := "", m.Location, s.municipalityAreas
+	if postcodes := s.postcodes[m.Name]; len(postcodes) > 0 {
+		id = postcodes[rng.Intn(len(postcodes))]
+		postcode, location, area = s.all[id].Code, s.all[id].Location, s.postcodeAreas
+	}
+
+	//gaussian jitter around the centroid, the distance follows a Rayleigh distribution
+	//jitters leaving the area are sampled again, the user stays at the centroid when all of them leave it
+	if s.jitterKm > 0 {
+		for attempt := 0; attempt < maxJitterAttempts; attempt++ {
+			distance := s.jitterKm * math.Sqrt(-2*math.Log(1-rng.Float64()))
+			jittered := geo.Destination(location, rng.Float64()*360, distance)
+			if area.contains(id, jittered) {
+				location = jittered
+				break
+			}
+		}
+	}
+
+	return postcode, location, nil
+}
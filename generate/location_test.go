@@ -0,0 +1,159 @@
+package generate
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+func TestPostcodeSampler(t *testing.T) {
+	postcodes, err := util.LoadPostcodes("../config/postcodes_sample.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampler, err := NewPostcodeSampler(postcodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 100; i++ {
+		postcode, location, err := sampler.Sample(rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//location is the centroid of the postcode
+		expected, err := postcodes.Geocode(postcode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if location != expected {
+			t.Errorf("Location of %s is incorrect, got '%v', want '%v'", postcode, location, expected)
+		}
+	}
+}
+
+func TestPopulationSampler(t *testing.T) {
+	municipalities := []util.Municipality{
+		{Name: "Enschede", Population: 900, Location: util.Geolocation{Latitude: 52.2215, Longitude: 6.8937}},
+		{Name: "Hengelo", Population: 100, Location: util.Geolocation{Latitude: 52.2659, Longitude: 6.7931}},
+		{Name: "Ghost town", Population: 0, Location: util.Geolocation{Latitude: 53, Longitude: 6}},
+	}
+	sampler, err := NewPopulationSampler(municipalities, nil, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(42))
+
+	n := 10000
+	counts := make([]int, len(municipalities))
+	distance := 0.0
+	for i := 0; i < n; i++ {
+		_, l, err := sampler.Sample(rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//closest municipality
+		closest, d := 0, -1.0
+		for j, m := range municipalities {
+			if dm := util.DistanceTo(l.Latitude, l.Longitude, m.Location.Latitude, m.Location.Longitude); d < 0 || dm < d {
+				closest, d = j, dm
+			}
+		}
+		counts[closest]++
+		distance += d
+	}
+
+	if share := float64(counts[0]) / float64(n); share < 0.88 || share > 0.92 {
+		t.Errorf("Share of users in Enschede is incorrect, got '%f', want '0.9'", share)
+	}
+	if counts[2] != 0 {
+		t.Errorf("Users in a municipality without population are incorrect, got '%d', want '0'", counts[2])
+	}
+	//mean of the Rayleigh distribution
+	if mean := distance / float64(n); math.Abs(mean-0.5*math.Sqrt(math.Pi/2)) > 0.02 {
+		t.Errorf("Mean jitter distance is incorrect, got '%f', want '%f'", mean, 0.5*math.Sqrt(math.Pi/2))
+	}
+
+	if _, err := NewPopulationSampler(municipalities[2:], nil, 0); err == nil {
+		t.Error("Expected an error for municipalities without population")
+	}
+}
+
+func TestPopulationSamplerPostcodes(t *testing.T) {
+	postcodes, err := util.LoadPostcodes("../config/postcodes_sample.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	municipalities, err := util.LoadMunicipalities("../config/municipalities_sample.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampler, err := NewPopulationSampler(municipalities, postcodes, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 100; i++ {
+		postcode, location, err := sampler.Sample(rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if postcode == "" {
+			continue
+		}
+		expected, err := postcodes.Geocode(postcode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if location != expected {
+			t.Errorf("Location of %s is incorrect, got '%v', want '%v'", postcode, location, expected)
+		}
+	}
+}
+
+func TestPopulationSamplerJitterArea(t *testing.T) {
+	postcodes, err := util.LoadPostcodes("../config/postcodes_sample.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	municipalities, err := util.LoadMunicipalities("../config/municipalities_sample.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampler, err := NewPopulationSampler(municipalities, postcodes, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(42))
+
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		postcode, location, err := sampler.Sample(rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//the closest postcode is the postcode of the user
+		closest, d := "", -1.0
+		for _, pc := range postcodes.Finest() {
+			if dp := util.DistanceTo(location.Latitude, location.Longitude, pc.Location.Latitude, pc.Location.Longitude); d < 0 || dp < d {
+				closest, d = pc.Code, dp
+			}
+		}
+		if closest != postcode {
+			t.Errorf("Location of %s is in the area of %s", postcode, closest)
+		}
+		if d > 0 {
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Error("Locations are not jittered")
+	}
+}
@@ -0,0 +1,263 @@
+package generate
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+//TasteConfig contains the parameters of the generative model of the orders
+//each tag and ingredient has a latent vector, a recipe sums the vectors of its tags and ingredients (scaled by 1/√n)
+//and a user has a latent taste vector, the affinity of a user for a recipe is the dot product of their vectors
+type TasteConfig struct {
+	//Dimensions is the number of dimensions of the latent vectors
+	Dimensions int `json:"dimensions"`
+	//MinOrders and MaxOrders bound the number of recipes ordered by a user
+	MinOrders int `json:"minOrders"`
+	MaxOrders int `json:"maxOrders"`
+	//MaxPreferences is the maximum number of food preferences (tags) declared by a user
+	MaxPreferences int `json:"maxPreferences"`
+	//PopularityExponent is the exponent of the Zipf law of the recipes popularity, 0 for a uniform popularity
+	PopularityExponent float64 `json:"popularityExponent"`
+	//Selectivity is the weight of the affinity against the popularity when choosing recipes, 0 to ignore the taste
+	Selectivity float64 `json:"selectivity"`
+	//RatingSlope is the rating increase for one standard deviation of affinity
+	RatingSlope float64 `json:"ratingSlope"`
+	//RatingNoise is the standard deviation of the gaussian noise added to ratings
+	RatingNoise float64 `json:"ratingNoise"`
+}
+
+//DefaultTasteConfig returns the parameters used to generate the orders when none are given
+func DefaultTasteConfig() TasteConfig {
+	return TasteConfig{
+		Dimensions: 8,
+		MinOrders:  1,
+		// assumption that a meal-sharing user will not have more than 50 orders
+		MaxOrders: 50,
+		// assumption that a meal-sharing user will not enter more than 12 tags
+		MaxPreferences:     12,
+		PopularityExponent: 1,
+		Selectivity:        1.5,
+		RatingSlope:        1,
+		RatingNoise:        0.5,
+	}
+}
+
+//Validate verifies the parameters
+func (c TasteConfig) Validate() error {
+	switch {
+	case c.Dimensions <= 0:
+		return errors.New("taste dimensions must be positive")
+	case c.MinOrders < 0 || c.MaxOrders < c.MinOrders:
+		return errors.New("orders bounds must verify 0 <= minOrders <= maxOrders")
+	case c.MaxPreferences < 0:
+		return errors.New("maximum number of preferences must not be negative")
+	case c.PopularityExponent < 0 || c.Selectivity < 0 || c.RatingNoise < 0:
+		return errors.New("popularity exponent, selectivity and rating noise must not be negative")
+	}
+
+	return nil
+}
+
+//tasteModel is the generative model of the orders of a recipes dataset
+type tasteModel struct {
+	config TasteConfig
+
+	//tags and ingredients latent vectors
+	features   []string
+	embeddings [][]float64
+	tags       []int
+
+	//recipes id, latent vector and log popularity
+	recipeIDs     []int
+	recipeLatent  [][]float64
+	logPopularity []float64
+}
+
+//newTasteModel draws the latent vectors of the tags and ingredients and the popularity of the recipes
+func newTasteModel(recipes dataframe.DataFrame, config TasteConfig, rng *rand.Rand) (*tasteModel, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	m := &tasteModel{config: config}
+
+	//tags and ingredients columns
+	var columns []int
+	for j, n := range recipes.Names() {
+		if strings.HasPrefix(n, "tag_") || strings.HasPrefix(n, "ingredient_") {
+			columns = append(columns, j)
+			if strings.HasPrefix(n, "tag_") {
+				m.tags = append(m.tags, len(m.features))
+			}
+			m.features = append(m.features, n)
+			m.embeddings = append(m.embeddings, gaussianVector(rng, config.Dimensions))
+		}
+	}
+
+	//recipes from their tags and ingredients
+	hasID := false
+	for _, n := range recipes.Names() {
+		hasID = hasID || n == "id"
+	}
+	if !hasID {
+		return nil, errors.New("recipes must have an id column")
+	}
+	ids := recipes.Col("id").Records()
+	for i, record := range recipes.Records()[1:] {
+		id, err := strconv.Atoi(ids[i])
+		if err != nil {
+			continue
+		}
+
+		latent := make([]float64, config.Dimensions)
+		count := 0
+		for f, j := range columns {
+			if record[j] != "1" {
+				continue
+			}
+			for d := range latent {
+				latent[d] += m.embeddings[f][d]
+			}
+			count++
+		}
+		if count > 0 {
+			for d := range latent {
+				latent[d] /= math.Sqrt(float64(count))
+			}
+		}
+
+		m.recipeIDs = append(m.recipeIDs, id)
+		m.recipeLatent = append(m.recipeLatent, latent)
+	}
+	if len(m.recipeIDs) == 0 {
+		return nil, errors.New("no recipe to order")
+	}
+
+	//long tail popularity, the popularity rank of the recipes is random
+	m.logPopularity = make([]float64, len(m.recipeIDs))
+	for rank, r := range rng.Perm(len(m.recipeIDs)) {
+		m.logPopularity[r] = -config.PopularityExponent * math.Log(float64(rank+1))
+	}
+
+	return m, nil
+}
+
+//gaussianVector returns a vector of standard normal values
+func gaussianVector(rng *rand.Rand, dimensions int) []float64 {
+	v := make([]float64, dimensions)
+	for d := range v {
+		v[d] = rng.NormFloat64()
+	}
+	return v
+}
+
+func dot(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+//standardize returns the z-scores of values
+func standardize(values []float64) []float64 {
+	mean, variance := 0.0, 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	std := math.Sqrt(variance / float64(len(values)))
+
+	z := make([]float64, len(values))
+	for i, v := range values {
+		if std > 0 {
+			z[i] = (v - mean) / std
+		}
+	}
+	return z
+}
+
+//userTaste draws the latent taste of a user
+func (m *tasteModel) userTaste(rng *rand.Rand) []float64 {
+	return gaussianVector(rng, m.config.Dimensions)
+}
+
+//preferences returns the tags a user likes the most, the user declares a random number of them up to MaxPreferences
+func (m *tasteModel) preferences(taste []float64, rng *rand.Rand) []string {
+	if len(m.tags) == 0 || m.config.MaxPreferences == 0 {
+		return nil
+	}
+
+	tags := make([]int, len(m.tags))
+	affinities := make(map[int]float64)
+	for i, t := range m.tags {
+		tags[i] = t
+		affinities[t] = dot(taste, m.embeddings[t])
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return affinities[tags[i]] > affinities[tags[j]]
+	})
+
+	n := rng.Intn(m.config.MaxPreferences + 1)
+	if n > len(tags) {
+		n = len(tags)
+	}
+	preferences := make([]string, n)
+	for i := range preferences {
+		preferences[i] = m.features[tags[i]]
+	}
+
+	return preferences
+}
+
+//orders returns the distinct recipes ordered by a user and their rating
+//recipes are drawn without replacement with a probability proportional to popularity · exp(selectivity · affinity)
+//ratings are 3 + slope · affinity + noise, rounded and bounded in [1, 5], with affinities standardized per user
+func (m *tasteModel) orders(taste []float64, rng *rand.Rand) ([]int, []int) {
+	affinities := make([]float64, len(m.recipeIDs))
+	for r, latent := range m.recipeLatent {
+		affinities[r] = dot(taste, latent)
+	}
+	affinities = standardize(affinities)
+
+	n := m.config.MinOrders + rng.Intn(m.config.MaxOrders-m.config.MinOrders+1)
+	if n > len(m.recipeIDs) {
+		n = len(m.recipeIDs)
+	}
+
+	//weighted sampling without replacement (Efraimidis-Spirakis) with log keys log(u) / w
+	keys := make([]weightedKey, len(m.recipeIDs))
+	for r := range keys {
+		logWeight := m.logPopularity[r] + m.config.Selectivity*affinities[r]
+		keys[r] = weightedKey{index: r, key: math.Log(1-rng.Float64()) * math.Exp(-logWeight)}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].key > keys[j].key
+	})
+
+	ids := make([]int, n)
+	ratings := make([]int, n)
+	for i := 0; i < n; i++ {
+		r := keys[i].index
+		ids[i] = m.recipeIDs[r]
+
+		rating := math.Round(3 + m.config.RatingSlope*affinities[r] + m.config.RatingNoise*rng.NormFloat64())
+		ratings[i] = int(math.Max(1, math.Min(5, rating)))
+	}
+
+	return ids, ratings
+}
+
+type weightedKey struct {
+	index int
+	key   float64
+}
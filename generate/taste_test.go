@@ -0,0 +1,177 @@
+package generate
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/go-gota/gota/dataframe"
+	"gonum.org/v1/gonum/stat"
+)
+
+//testRecipes returns recipes with random tags and ingredients, without scraping
+func testRecipes(nbRecipes, nbTags, nbIngredients int) dataframe.DataFrame {
+	rng := rand.New(rand.NewSource(1))
+
+	headers := []string{"id", "title", "totalTime"}
+	for i := 0; i < nbTags; i++ {
+		headers = append(headers, fmt.Sprintf("tag_%d", i))
+	}
+	for i := 0; i < nbIngredients; i++ {
+		headers = append(headers, fmt.Sprintf("ingredient_%d", i))
+	}
+
+	records := [][]string{headers}
+	for r := 1; r <= nbRecipes; r++ {
+		record := []string{strconv.Itoa(r), fmt.Sprintf("Recipe %d", r), strconv.Itoa(10 + rng.Intn(80))}
+		for i := 0; i < nbTags+nbIngredients; i++ {
+			record = append(record, strconv.Itoa(rng.Intn(2)))
+		}
+		records = append(records, record)
+	}
+
+	return dataframe.LoadRecords(records)
+}
+
+func TestTasteConfigValidate(t *testing.T) {
+	config := DefaultTasteConfig()
+	if err := config.Validate(); err != nil {
+		t.Errorf("Default configuration should be valid, got '%v'", err)
+	}
+
+	config.MinOrders = 60
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an error for a minimum number of orders above the maximum")
+	}
+}
+
+func TestTasteModelOrders(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+
+	config := DefaultTasteConfig()
+	m, err := newTasteModel(testRecipes(200, 10, 20), config, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var affinities, ratings []float64
+	for u := 0; u < 200; u++ {
+		taste := m.userTaste(rng)
+		ids, userRatings := m.orders(taste, rng)
+
+		if len(ids) < config.MinOrders || len(ids) > config.MaxOrders {
+			t.Errorf("Number of orders is incorrect, got '%d', want between '%d' and '%d'", len(ids), config.MinOrders, config.MaxOrders)
+		}
+		seen := make(map[int]bool)
+		for _, id := range ids {
+			if seen[id] {
+				t.Errorf("Recipe %d is ordered twice", id)
+			}
+			seen[id] = true
+		}
+
+		//affinity of the ordered recipes
+		all := make([]float64, len(m.recipeIDs))
+		for r := range m.recipeIDs {
+			all[r] = dot(taste, m.recipeLatent[r])
+		}
+		all = standardize(all)
+		for i, id := range ids {
+			if userRatings[i] < 1 || userRatings[i] > 5 {
+				t.Errorf("Rating is incorrect, got '%d', want between '1' and '5'", userRatings[i])
+			}
+			affinities = append(affinities, all[id-1])
+			ratings = append(ratings, float64(userRatings[i]))
+		}
+
+		//declared preferences are the tags the user likes the most
+		preferences := m.preferences(taste, rng)
+		if len(preferences) > config.MaxPreferences {
+			t.Errorf("Number of preferences is incorrect, got '%d', want at most '%d'", len(preferences), config.MaxPreferences)
+		}
+		declared := make(map[string]bool)
+		for _, p := range preferences {
+			declared[p] = true
+		}
+		for _, p := range preferences {
+			for _, tag := range m.tags {
+				if !declared[m.features[tag]] && dot(taste, m.embeddings[tag]) > tagAffinity(m, taste, p) {
+					t.Errorf("Tag %s is preferred to the declared preference %s", m.features[tag], p)
+				}
+			}
+		}
+	}
+
+	if c := stat.Correlation(affinities, ratings, nil); c < 0.6 {
+		t.Errorf("Correlation of ratings and affinities is incorrect, got '%f', want at least '0.6'", c)
+	}
+}
+
+func TestTasteModelIDColumn(t *testing.T) {
+	recipes := testRecipes(20, 3, 5)
+	names := append(recipes.Names()[1:], "id")
+
+	//the id column is found by name
+	m, err := newTasteModel(recipes.Select(names), DefaultTasteConfig(), rand.New(rand.NewSource(42)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, _ := recipes.Col("id").Int()
+	if !reflect.DeepEqual(m.recipeIDs, ids) {
+		t.Errorf("Recipes ids are incorrect, got '%v', want '%v'", m.recipeIDs, ids)
+	}
+
+	if _, err := newTasteModel(recipes.Drop("id"), DefaultTasteConfig(), rand.New(rand.NewSource(42))); err == nil {
+		t.Error("Expected an error for recipes without id column")
+	}
+}
+
+func TestTasteModelPopularity(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+
+	//popularity only
+	config := DefaultTasteConfig()
+	config.Selectivity = 0
+	config.MinOrders, config.MaxOrders = 5, 5
+	m, err := newTasteModel(testRecipes(200, 10, 20), config, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[int]int)
+	total := 0
+	for u := 0; u < 1000; u++ {
+		ids, _ := m.orders(m.userTaste(rng), rng)
+		for _, id := range ids {
+			counts[id]++
+			total++
+		}
+	}
+
+	//the 10% most popular recipes are ordered much more than 10% of the times
+	var sorted []int
+	for _, c := range counts {
+		sorted = append(sorted, c)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	head := 0
+	for _, c := range sorted[:20] {
+		head += c
+	}
+	if share := float64(head) / float64(total); share < 0.4 {
+		t.Errorf("Share of orders of the most popular recipes is incorrect, got '%f', want at least '0.4'", share)
+	}
+}
+
+//tagAffinity returns the affinity of a user for a tag
+func tagAffinity(m *tasteModel, taste []float64, tag string) float64 {
+	for _, f := range m.tags {
+		if m.features[f] == tag {
+			return dot(taste, m.embeddings[f])
+		}
+	}
+	return 0
+}
@@ -0,0 +1,164 @@
+//Package geo contains geodesic computations on locations given in degrees
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+//Point contains coordinates of a position in degrees
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+//EarthRadius is the mean radius of the Earth in km
+const EarthRadius = 6371.01
+
+//WGS84 ellipsoid
+const (
+	wgs84A = 6378.137
+	wgs84F = 1 / 298.257223563
+	wgs84B = wgs84A * (1 - wgs84F)
+)
+
+//ErrNoConvergence is returned by Vincenty when the formula does not converge, for nearly antipodal points
+var ErrNoConvergence = errors.New("vincenty formula failed to converge")
+
+func toRad(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func toDeg(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+//normalizeLongitude returns the longitude in [-180, 180)
+func normalizeLongitude(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+//Haversine calculates the great circle distance in km between two points on a sphere
+//it is accurate for identical and very close points, unlike the spherical law of cosines
+func Haversine(a, b Point) float64 {
+	lat1, lat2 := toRad(a.Latitude), toRad(b.Latitude)
+	deltaLat := lat2 - lat1
+	deltaLon := toRad(b.Longitude - a.Longitude)
+
+	//h = sin²(Δlat/2) + cos(lat1) · cos(lat2) · sin²(Δlon/2), dist = 2 · R · arcsin(√h)
+	h := math.Pow(math.Sin(deltaLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(deltaLon/2), 2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(1, h)))
+}
+
+//Vincenty calculates the geodesic distance in km between two points on the WGS84 ellipsoid
+//https://en.wikipedia.org/wiki/Vincenty%27s_formulae
+func Vincenty(a, b Point) (float64, error) {
+	L := toRad(b.Longitude - a.Longitude)
+	U1 := math.Atan((1 - wgs84F) * math.Tan(toRad(a.Latitude)))
+	U2 := math.Atan((1 - wgs84F) * math.Tan(toRad(b.Latitude)))
+	sinU1, cosU1 := math.Sincos(U1)
+	sinU2, cosU2 := math.Sincos(U2)
+
+	lambda := L
+	var sinSigma, cosSigma, sigma, cos2Alpha, cos2SigmaM float64
+	for i := 0; ; i++ {
+		if i == 200 {
+			return 0, ErrNoConvergence
+		}
+
+		sinLambda, cosLambda := math.Sincos(lambda)
+		sinSigma = math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			//identical points
+			return 0, nil
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cos2Alpha = 1 - sinAlpha*sinAlpha
+		//equatorial line
+		cos2SigmaM = 0
+		if cos2Alpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cos2Alpha
+		}
+
+		C := wgs84F / 16 * cos2Alpha * (4 + wgs84F*(4-3*cos2Alpha))
+		previous := lambda
+		lambda = L + (1-C)*wgs84F*sinAlpha*(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-previous) < 1e-12 {
+			break
+		}
+	}
+
+	u2 := cos2Alpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+	A := 1 + u2/16384*(4096+u2*(-768+u2*(320-175*u2)))
+	B := u2 / 1024 * (256 + u2*(-128+u2*(74-47*u2)))
+	deltaSigma := B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+
+	return wgs84B * A * (sigma - deltaSigma), nil
+}
+
+//Bearing calculates the initial bearing in degrees, clockwise from the north in [0, 360), to go from a to b on a great circle
+func Bearing(a, b Point) float64 {
+	lat1, lat2 := toRad(a.Latitude), toRad(b.Latitude)
+	deltaLon := toRad(b.Longitude - a.Longitude)
+
+	y := math.Sin(deltaLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(deltaLon)
+
+	return math.Mod(toDeg(math.Atan2(y, x))+360, 360)
+}
+
+//Destination calculates the point reached when travelling km from a point with an initial bearing in degrees on a great circle
+func Destination(p Point, bearing, km float64) Point {
+	lat := toRad(p.Latitude)
+	lon := toRad(p.Longitude)
+	theta := toRad(bearing)
+	delta := km / EarthRadius
+
+	lat2 := math.Asin(math.Sin(lat)*math.Cos(delta) + math.Cos(lat)*math.Sin(delta)*math.Cos(theta))
+	lon2 := lon + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat), math.Cos(delta)-math.Sin(lat)*math.Sin(lat2))
+
+	return Point{
+		Latitude:  toDeg(lat2),
+		Longitude: normalizeLongitude(toDeg(lon2)),
+	}
+}
+
+//BoundingBox computes the bounding coordinates of the points at most km away from a point
+//http://janmatuschek.de/LatitudeLongitudeBoundingCoordinates
+//when the box crosses the antimeridian, the minimum longitude is greater than the maximum longitude
+//when it contains a pole, it covers all longitudes
+func BoundingBox(p Point, km float64) (min, max Point) {
+	//distance in radians on a great circle
+	radDist := km / EarthRadius
+	radLat := toRad(p.Latitude)
+	radLon := toRad(p.Longitude)
+
+	minLat := radLat - radDist
+	maxLat := radLat + radDist
+
+	var minLon, maxLon float64
+	if minLat > -math.Pi/2 && maxLat < math.Pi/2 {
+		deltaLon := math.Asin(math.Sin(radDist) / math.Cos(radLat))
+		minLon = radLon - deltaLon
+		if minLon < -math.Pi {
+			minLon += 2 * math.Pi
+		}
+		maxLon = radLon + deltaLon
+		if maxLon > math.Pi {
+			maxLon -= 2 * math.Pi
+		}
+	} else {
+		minLat = math.Max(minLat, -math.Pi/2)
+		maxLat = math.Min(maxLat, math.Pi/2)
+		minLon = -math.Pi
+		maxLon = math.Pi
+	}
+
+	return Point{Latitude: toDeg(minLat), Longitude: toDeg(minLon)}, Point{Latitude: toDeg(maxLat), Longitude: toDeg(maxLon)}
+}
@@ -0,0 +1,171 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-6
+
+func TestHaversine(t *testing.T) {
+	oneDegree := EarthRadius * math.Pi / 180
+
+	tests := []struct {
+		name     string
+		a, b     Point
+		expected float64
+	}{
+		{"identical points", Point{52.210976, 6.883238}, Point{52.210976, 6.883238}, 0},
+		{"quarter of the equator", Point{0, 0}, Point{0, 90}, EarthRadius * math.Pi / 2},
+		{"antimeridian", Point{0, 179.5}, Point{0, -179.5}, oneDegree},
+		{"north pole", Point{90, 0}, Point{90, 120}, 0},
+		{"over the north pole", Point{89, 0}, Point{89, 180}, 2 * oneDegree},
+		{"south pole", Point{-90, 0}, Point{-89, 45}, oneDegree},
+		{"one meter", Point{0, 0}, Point{0, 0.001 / oneDegree}, 0.001},
+	}
+
+	for _, test := range tests {
+		d := Haversine(test.a, test.b)
+		if math.IsNaN(d) || math.Abs(d-test.expected) > tolerance {
+			t.Errorf("Distance of %s is incorrect, got '%f', want '%f'", test.name, d, test.expected)
+		}
+	}
+}
+
+func TestVincenty(t *testing.T) {
+	//Flinders Peak to Buninyong, from Vincenty's paper
+	d, err := Vincenty(Point{-37.95103342, 144.42486789}, Point{-37.65282114, 143.92649554})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(d-54.972271) > tolerance {
+		t.Errorf("Distance is incorrect, got '%f', want '54.972271'", d)
+	}
+
+	d, err = Vincenty(Point{52.210976, 6.883238}, Point{52.210976, 6.883238})
+	if err != nil || d != 0 {
+		t.Errorf("Distance of identical points is incorrect, got '%f' (%v), want '0'", d, err)
+	}
+
+	//a quarter of the equator on the ellipsoid
+	d, err = Vincenty(Point{0, 179}, Point{0, -91})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(d-wgs84A*math.Pi/2) > tolerance {
+		t.Errorf("Distance across the antimeridian is incorrect, got '%f', want '%f'", d, wgs84A*math.Pi/2)
+	}
+
+	if _, err := Vincenty(Point{0, 0}, Point{0.5, 179.7}); err != ErrNoConvergence {
+		t.Errorf("Nearly antipodal points should not converge, got '%v'", err)
+	}
+}
+
+func TestBearing(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     Point
+		expected float64
+	}{
+		{"north", Point{0, 0}, Point{1, 0}, 0},
+		{"east", Point{0, 0}, Point{0, 1}, 90},
+		{"south", Point{0, 0}, Point{-1, 0}, 180},
+		{"west", Point{0, 0}, Point{0, -1}, 270},
+		{"east across the antimeridian", Point{0, 179.5}, Point{0, -179.5}, 90},
+		{"to the north pole", Point{52, 6}, Point{90, 0}, 0},
+	}
+
+	for _, test := range tests {
+		b := Bearing(test.a, test.b)
+		if math.Abs(b-test.expected) > tolerance {
+			t.Errorf("Bearing %s is incorrect, got '%f', want '%f'", test.name, b, test.expected)
+		}
+	}
+}
+
+func TestDestination(t *testing.T) {
+	oneDegree := EarthRadius * math.Pi / 180
+
+	tests := []struct {
+		name     string
+		p        Point
+		bearing  float64
+		km       float64
+		expected Point
+	}{
+		{"east across the antimeridian", Point{0, 179.5}, 90, oneDegree, Point{0, -179.5}},
+		{"west across the antimeridian", Point{0, -179.5}, 270, oneDegree, Point{0, 179.5}},
+		{"over the north pole", Point{89, 0}, 0, 2 * oneDegree, Point{89, -180}},
+	}
+
+	for _, test := range tests {
+		d := Destination(test.p, test.bearing, test.km)
+		if math.Abs(d.Latitude-test.expected.Latitude) > tolerance || math.Abs(d.Longitude-test.expected.Longitude) > tolerance {
+			t.Errorf("Destination %s is incorrect, got '%v', want '%v'", test.name, d, test.expected)
+		}
+	}
+
+	//any bearing goes south from the north pole
+	if d := Destination(Point{90, 0}, 180, oneDegree); math.Abs(d.Latitude-89) > tolerance {
+		t.Errorf("Destination from the north pole is incorrect, got '%v', want a latitude of '89'", d)
+	}
+
+	//going back and forth
+	a, b := Point{52.210976, 6.883238}, Point{52.370216, 4.895168}
+	d := Destination(a, Bearing(a, b), Haversine(a, b))
+	if Haversine(d, b) > tolerance {
+		t.Errorf("Destination is incorrect, got '%v', want '%v'", d, b)
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	//crossing the antimeridian
+	min, max := BoundingBox(Point{0, 179.9}, 50)
+	if min.Longitude <= max.Longitude || min.Longitude > 179.9 || max.Longitude < -180 {
+		t.Errorf("Bounding box across the antimeridian is incorrect, got '%v' '%v'", min, max)
+	}
+
+	//containing the pole
+	min, max = BoundingBox(Point{89.9, 0}, 50)
+	if max.Latitude != 90 || min.Longitude != -180 || max.Longitude != 180 {
+		t.Errorf("Bounding box containing the pole is incorrect, got '%v' '%v'", min, max)
+	}
+
+	//all points within the distance are in the box
+	center := Point{52.210976, 6.883238}
+	min, max = BoundingBox(center, 10)
+	for bearing := 0.0; bearing < 360; bearing += 15 {
+		p := Destination(center, bearing, 10)
+		if p.Latitude < min.Latitude-tolerance || p.Latitude > max.Latitude+tolerance ||
+			p.Longitude < min.Longitude-tolerance || p.Longitude > max.Longitude+tolerance {
+			t.Errorf("Point %v at bearing %.0f is not in the bounding box '%v' '%v'", p, bearing, min, max)
+		}
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	netherlands := Polygon{{50.75, 3.36}, {53.47, 3.36}, {53.47, 7.23}, {50.75, 7.23}}
+	if !netherlands.Contains(Point{52.210976, 6.883238}) {
+		t.Error("Enschede should be in the polygon")
+	}
+	if netherlands.Contains(Point{48.856613, 2.352222}) {
+		t.Error("Paris should not be in the polygon")
+	}
+
+	//Fiji crosses the antimeridian
+	fiji := Polygon{{-16, 179}, {-16, -179}, {-18, -179}, {-18, 179}}
+	for _, p := range []Point{{-17, 179.9}, {-17, -179.9}, {-17, 180}} {
+		if !fiji.Contains(p) {
+			t.Errorf("Point %v should be in the polygon crossing the antimeridian", p)
+		}
+	}
+	for _, p := range []Point{{-17, 0}, {-17, 178}, {-17, -178}, {-15, 179.5}} {
+		if fiji.Contains(p) {
+			t.Errorf("Point %v should not be in the polygon crossing the antimeridian", p)
+		}
+	}
+
+	if (Polygon{{0, 0}, {1, 1}}).Contains(Point{0.5, 0.5}) {
+		t.Error("A polygon needs at least 3 vertices")
+	}
+}
@@ -0,0 +1,42 @@
+package geo
+
+//Polygon is a closed area delimited by its vertices, the last vertex is connected to the first one
+//edges are the shortest way in longitude between vertices, so a polygon may cross the antimeridian
+type Polygon []Point
+
+//Contains returns if the point is inside the polygon using ray casting
+//longitudes are unwrapped around the first vertex so polygons crossing the antimeridian are supported
+func (poly Polygon) Contains(p Point) bool {
+	if len(poly) < 3 {
+		return false
+	}
+
+	//unwrap longitudes relative to the first vertex
+	vertices := make([]Point, len(poly))
+	vertices[0] = poly[0]
+	for i := 1; i < len(poly); i++ {
+		//each edge takes the shortest way from the previous vertex
+		vertices[i] = Point{
+			Latitude:  poly[i].Latitude,
+			Longitude: vertices[i-1].Longitude + normalizeLongitude(poly[i].Longitude-vertices[i-1].Longitude),
+		}
+	}
+	x := poly[0].Longitude + normalizeLongitude(p.Longitude-poly[0].Longitude)
+
+	//unwrapped vertices may go beyond a half turn from the first vertex
+	for _, lon := range []float64{x, x - 360, x + 360} {
+		inside := false
+		for i, j := 0, len(vertices)-1; i < len(vertices); j, i = i, i+1 {
+			a, b := vertices[i], vertices[j]
+			if (a.Latitude > p.Latitude) != (b.Latitude > p.Latitude) &&
+				lon < (b.Longitude-a.Longitude)*(p.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+				inside = !inside
+			}
+		}
+		if inside {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,382 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/julienrbrt/ut_research_project/util"
+	"gonum.org/v1/gonum/stat"
+)
+
+//ErrInfeasible is returned when no recipe can be planned without violating the constraints
+var ErrInfeasible = errors.New("no recipe satisfies the meal plan constraints")
+
+//Bounds bounds the mean daily value of a nutrient, a zero bound is not enforced
+type Bounds struct {
+	Min float64
+	Max float64
+}
+
+//Config contains the constraints of a meal plan
+type Config struct {
+	//Days is the number of dinners to plan
+	Days int
+	//MaxTotalTime is the cooking time budget of all dinners in minutes, 0 without budget
+	MaxTotalTime int
+	//MainIngredients contains the keywords of the ingredients of each main ingredient (see util.ContainsCompound)
+	//a main ingredient is cooked at most once, e.g. chicken whether it is a fillet or a leg
+	MainIngredients map[string][]string
+	//MainExceptions contains the ingredients containing a keyword of a main ingredient without being one (e.g. currypasta)
+	MainExceptions map[string][]string
+	//MaxTagRepeat is the maximum number of dinners sharing a tag, 0 without limit
+	MaxTagRepeat int
+	//IgnoredTags are not counted for the tags diversity, like the tags shared by all dinners
+	IgnoredTags []string
+	//Nutrition bounds the mean daily value of the nutrition columns (e.g. nutrition_kcal)
+	//recipes without a value of a bounded nutrient are not planned
+	Nutrition map[string]Bounds
+	//ReuseBonus is the score gained by a recipe per ingredient shared with the planned dinners, in standard deviations of the scores
+	ReuseBonus float64
+	//Staples are the ingredient columns always at home, they are not counted as reused
+	Staples []string
+}
+
+//DefaultConfig returns the constraints of a week of dinners
+func DefaultConfig() Config {
+	return Config{
+		Days: 7,
+		MainIngredients: map[string][]string{
+			"chicken":   {"kip", "kalkoen"},
+			"beef":      {"rund", "gehakt", "biefstuk"},
+			"pork":      {"varken", "spek", "ham", "worst", "chorizo"},
+			"fish":      {"vis", "zalm", "kabeljauw", "tonijn", "makreel", "pangasius"},
+			"shellfish": {"garnaal", "garnalen", "mosselen", "scampi"},
+			"soy":       {"tofu", "tempeh"},
+			"pasta":     {"pasta", "spaghetti", "penne", "macaroni", "tagliatelle", "lasagne"},
+			"rice":      {"rijst"},
+			"grains":    {"couscous", "bulgur", "quinoa"},
+			"noodles":   {"noedels", "mie"},
+			"potato":    {"aardappel", "krieltjes"},
+			"legumes":   {"linzen", "kikkererwten", "bonen"},
+		},
+		MainExceptions: map[string][]string{
+			"chicken": {"bouillon"},
+			"beef":    {"bouillon", "kipgehakt", "kalkoengehakt", "varkensgehakt"},
+			"pork":    {"hamburger", "spekkoek"},
+			"fish":    {"vissaus", "bouillon"},
+			"pasta":   {"currypasta", "kerriepasta", "tomatenpasta", "sesampasta", "gemberpasta", "knoflookpasta", "kruidenpasta", "pindapasta", "chilipasta"},
+			"rice":    {"rijstazijn", "rijstwijn", "rijstvel", "rijstpapier", "rijstnoedel"},
+			"noodles": {"mierikswortel"},
+			"legumes": {"sperziebonen", "snijbonen", "koffiebonen"},
+		},
+		MaxTagRepeat: 3,
+		IgnoredTags:  []string{"tag_hoofdgerecht"},
+		ReuseBonus:   0.1,
+		Staples:      []string{"ingredient_zout", "ingredient_peper", "ingredient_olie", "ingredient_boter", "ingredient_water", "ingredient_suiker"},
+	}
+}
+
+//Validate verifies the constraints
+func (c Config) Validate() error {
+	switch {
+	case c.Days <= 0:
+		return errors.New("number of days must be positive")
+	case c.MaxTotalTime < 0 || c.MaxTagRepeat < 0:
+		return errors.New("time budget and tag repeat must not be negative")
+	case c.ReuseBonus < 0:
+		return errors.New("reuse bonus must not be negative")
+	}
+	for n, b := range c.Nutrition {
+		if b.Max > 0 && b.Min > b.Max {
+			return fmt.Errorf("minimum of %s must not exceed its maximum", n)
+		}
+	}
+
+	return nil
+}
+
+//Day is a planned dinner
+type Day struct {
+	RecipeID string
+	Title    string
+	//Score is the recommendation score of the recipe
+	Score   float64
+	Minutes int
+	//Reused contains the ingredients of the recipe already bought for previous dinners
+	Reused []string
+}
+
+//Plan is a meal plan
+type Plan struct {
+	Days      []Day
+	TotalTime int
+	//Nutrition contains the mean daily value of the bounded nutrients
+	Nutrition map[string]float64
+}
+
+//recipe contains the features of a candidate recipe
+type recipe struct {
+	id          string
+	title       string
+	score       float64
+	z           float64
+	minutes     int
+	main        []string
+	tags        []string
+	ingredients []string
+	nutrition   map[string]float64
+}
+
+//candidates returns the features of the scored recipes, sorted by score
+func candidates(scores map[string]float64, recipes dataframe.DataFrame, config Config) ([]recipe, error) {
+	names := recipes.Names()
+	if !contains(names, "id") {
+		return nil, errors.New("recipes must have an id column")
+	}
+	ids := recipes.Col("id").Records()
+	for n := range config.Nutrition {
+		if !contains(names, n) {
+			return nil, fmt.Errorf("recipes have no %s column", n)
+		}
+	}
+
+	ignored := make(map[string]bool)
+	for _, n := range append(append([]string{}, config.IgnoredTags...), config.Staples...) {
+		ignored[n] = true
+	}
+
+	var values []float64
+	var candidates []recipe
+	for i, record := range recipes.Records()[1:] {
+		score, ok := scores[ids[i]]
+		if !ok {
+			continue
+		}
+
+		r := recipe{id: ids[i], score: score, nutrition: make(map[string]float64)}
+		unknown := false
+		for j, n := range names {
+			switch {
+			case n == "title":
+				r.title = record[j]
+			case n == "totalTime":
+				r.minutes, _ = strconv.Atoi(record[j])
+			case strings.HasPrefix(n, "tag_") && record[j] == "1" && !ignored[n]:
+				r.tags = append(r.tags, n)
+			case strings.HasPrefix(n, "ingredient_") && record[j] == "1" && !ignored[n]:
+				r.ingredients = append(r.ingredients, n)
+				for main, keywords := range config.MainIngredients {
+					if util.ContainsCompound(strings.TrimPrefix(n, "ingredient_"), keywords, config.MainExceptions[main]) && !contains(r.main, main) {
+						r.main = append(r.main, main)
+					}
+				}
+			}
+			if _, ok := config.Nutrition[n]; ok {
+				if record[j] == "" || record[j] == "NaN" {
+					unknown = true
+					continue
+				}
+				v, err := strconv.ParseFloat(record[j], 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s of recipe %s: %v", n, r.id, err)
+				}
+				r.nutrition[n] = v
+			}
+		}
+		//the bounds cannot be verified for recipes without nutrition values
+		if unknown {
+			continue
+		}
+		candidates = append(candidates, r)
+		values = append(values, score)
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("no scored recipe to plan")
+	}
+
+	//standardized scores so the reuse bonus does not depend on the model
+	mean, std := stat.MeanStdDev(values, nil)
+	for i := range candidates {
+		if std > 0 {
+			candidates[i].z = (candidates[i].score - mean) / std
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	return candidates, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+//planner contains the state of a plan being built
+type planner struct {
+	config Config
+
+	planned     map[string]bool
+	mains       map[string]bool
+	tags        map[string]int
+	ingredients map[string]bool
+	minutes     int
+	nutrition   map[string]float64
+
+	//smallest time and nutrition values of the candidates, to keep the remaining days feasible
+	minMinutes   int
+	minNutrition map[string]float64
+	maxNutrition map[string]float64
+}
+
+func newPlanner(candidates []recipe, config Config) *planner {
+	p := &planner{
+		config:       config,
+		planned:      make(map[string]bool),
+		mains:        make(map[string]bool),
+		tags:         make(map[string]int),
+		ingredients:  make(map[string]bool),
+		nutrition:    make(map[string]float64),
+		minMinutes:   math.MaxInt32,
+		minNutrition: make(map[string]float64),
+		maxNutrition: make(map[string]float64),
+	}
+	for n := range config.Nutrition {
+		p.minNutrition[n] = math.Inf(1)
+		p.maxNutrition[n] = math.Inf(-1)
+	}
+	for _, r := range candidates {
+		if r.minutes < p.minMinutes {
+			p.minMinutes = r.minutes
+		}
+		for n, v := range r.nutrition {
+			p.minNutrition[n] = math.Min(p.minNutrition[n], v)
+			p.maxNutrition[n] = math.Max(p.maxNutrition[n], v)
+		}
+	}
+
+	return p
+}
+
+//feasible returns if a recipe can be planned with remaining dinners left to plan after it
+func (p *planner) feasible(r recipe, remaining int) bool {
+	if p.planned[r.id] {
+		return false
+	}
+	for _, m := range r.main {
+		if p.mains[m] {
+			return false
+		}
+	}
+	if p.config.MaxTagRepeat > 0 {
+		for _, t := range r.tags {
+			if p.tags[t] >= p.config.MaxTagRepeat {
+				return false
+			}
+		}
+	}
+
+	//the remaining dinners must still fit in the time budget
+	if p.config.MaxTotalTime > 0 && p.minutes+r.minutes+remaining*p.minMinutes > p.config.MaxTotalTime {
+		return false
+	}
+
+	//the remaining dinners must still be able to reach the nutrition bounds
+	days := float64(p.config.Days)
+	for n, b := range p.config.Nutrition {
+		total := p.nutrition[n] + r.nutrition[n]
+		if b.Max > 0 && total+float64(remaining)*p.minNutrition[n] > b.Max*days {
+			return false
+		}
+		if b.Min > 0 && total+float64(remaining)*p.maxNutrition[n] < b.Min*days {
+			return false
+		}
+	}
+
+	return true
+}
+
+//reused returns the ingredients of a recipe already used by the planned dinners
+func (p *planner) reused(r recipe) []string {
+	var reused []string
+	for _, i := range r.ingredients {
+		if p.ingredients[i] {
+			reused = append(reused, i)
+		}
+	}
+	return reused
+}
+
+//add plans a recipe
+func (p *planner) add(r recipe) Day {
+	day := Day{RecipeID: r.id, Title: r.title, Score: r.score, Minutes: r.minutes, Reused: p.reused(r)}
+
+	p.planned[r.id] = true
+	for _, m := range r.main {
+		p.mains[m] = true
+	}
+	for _, t := range r.tags {
+		p.tags[t]++
+	}
+	for _, i := range r.ingredients {
+		p.ingredients[i] = true
+	}
+	p.minutes += r.minutes
+	for n, v := range r.nutrition {
+		p.nutrition[n] += v
+	}
+
+	return day
+}
+
+//Generate plans the dinners with the best scores satisfying the constraints
+//scores are the recommendation scores of the recipes that can be planned (e.g. predicted ratings), other recipes are never planned
+//each day the recipe with the best standardized score plus its ingredients reuse bonus is chosen among the recipes
+//that keep the plan feasible: no repeated main ingredient or recipe, tags diversity, time budget and nutrition bounds
+func Generate(scores map[string]float64, recipes dataframe.DataFrame, config Config) (*Plan, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	candidates, err := candidates(scores, recipes, config)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newPlanner(candidates, config)
+	plan := &Plan{Nutrition: make(map[string]float64)}
+	for d := 0; d < config.Days; d++ {
+		best, bestValue := -1, math.Inf(-1)
+		for i, r := range candidates {
+			if !p.feasible(r, config.Days-d-1) {
+				continue
+			}
+			value := r.z + config.ReuseBonus*float64(len(p.reused(r)))
+			if value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+		if best < 0 {
+			return nil, fmt.Errorf("%w: day %d", ErrInfeasible, d+1)
+		}
+
+		plan.Days = append(plan.Days, p.add(candidates[best]))
+	}
+
+	plan.TotalTime = p.minutes
+	for n, v := range p.nutrition {
+		plan.Nutrition[n] = v / float64(config.Days)
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,181 @@
+package plan
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+func testRecipes() dataframe.DataFrame {
+	return dataframe.LoadRecords([][]string{
+		{"id", "title", "totalTime", "nutrition_kcal", "tag_hoofdgerecht", "tag_snel", "ingredient_kipfilet", "ingredient_kippendij", "ingredient_spaghetti", "ingredient_tofu", "ingredient_zalm", "ingredient_ui", "ingredient_paprika", "ingredient_sla", "ingredient_zout"},
+		{"1", "Kip pasta", "30", "700", "1", "1", "1", "0", "1", "0", "0", "1", "0", "0", "1"},
+		{"2", "Kip curry", "40", "600", "1", "0", "0", "1", "0", "0", "0", "1", "0", "0", "1"},
+		{"3", "Pasta pesto", "20", "650", "1", "1", "0", "0", "1", "0", "0", "0", "0", "0", "1"},
+		{"4", "Tofu wok", "25", "500", "1", "1", "0", "0", "0", "1", "0", "1", "1", "0", "1"},
+		{"5", "Zalm uit de oven", "35", "550", "1", "0", "0", "0", "0", "0", "1", "0", "0", "0", "1"},
+		{"6", "Salade", "15", "300", "1", "1", "0", "0", "0", "0", "0", "1", "0", "1", "1"},
+	})
+}
+
+func testScores() map[string]float64 {
+	return map[string]float64{"1": 5, "2": 4.8, "3": 4.6, "4": 4, "5": 3.5, "6": 3}
+}
+
+func planned(p *Plan) []string {
+	var ids []string
+	for _, d := range p.Days {
+		ids = append(ids, d.RecipeID)
+	}
+	return ids
+}
+
+func TestGenerate(t *testing.T) {
+	config := DefaultConfig()
+	config.Days = 3
+	config.ReuseBonus = 0
+
+	tests := []struct {
+		name   string
+		update func(c *Config)
+		want   []string
+	}{
+		//chicken and pasta of the first dinner are not cooked again
+		{"variety", func(c *Config) {}, []string{"1", "4", "5"}},
+		{"time budget", func(c *Config) { c.MaxTotalTime = 70 }, []string{"1", "4", "6"}},
+		{"nutrition", func(c *Config) { c.Nutrition = map[string]Bounds{"nutrition_kcal": {Max: 500}} }, []string{"1", "4", "6"}},
+		//a single quick dinner
+		{"tags diversity", func(c *Config) { c.Days, c.MaxTagRepeat = 2, 1 }, []string{"1", "5"}},
+		//the salad reuses the onions of the previous dinners
+		{"ingredients reuse", func(c *Config) { c.ReuseBonus = 2 }, []string{"1", "4", "6"}},
+	}
+
+	for _, test := range tests {
+		c := config
+		test.update(&c)
+
+		p, err := Generate(testScores(), testRecipes(), c)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := planned(p); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Plan with %s is incorrect, got '%v', want '%v'", test.name, got, test.want)
+		}
+		if c.MaxTotalTime > 0 && p.TotalTime > c.MaxTotalTime {
+			t.Errorf("Plan with %s exceeds the time budget, got '%d', want at most '%d'", test.name, p.TotalTime, c.MaxTotalTime)
+		}
+	}
+}
+
+func TestGenerateReused(t *testing.T) {
+	config := DefaultConfig()
+	config.Days = 3
+	config.ReuseBonus = 2
+
+	p, err := Generate(testScores(), testRecipes(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//salt is a staple
+	if !reflect.DeepEqual(p.Days[2].Reused, []string{"ingredient_ui"}) {
+		t.Errorf("Reused ingredients are incorrect, got '%v', want '%v'", p.Days[2].Reused, []string{"ingredient_ui"})
+	}
+	if p.TotalTime != 70 {
+		t.Errorf("Total time is incorrect, got '%d', want '%d'", p.TotalTime, 70)
+	}
+}
+
+func TestGenerateNutrition(t *testing.T) {
+	config := DefaultConfig()
+	config.Days = 3
+	config.Nutrition = map[string]Bounds{"nutrition_kcal": {Min: 400, Max: 500}}
+
+	p, err := Generate(testScores(), testRecipes(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kcal := p.Nutrition["nutrition_kcal"]; kcal < 400 || kcal > 500 {
+		t.Errorf("Mean kcal is incorrect, got '%f', want between '400' and '500'", kcal)
+	}
+
+	//recipes without kcal are not planned
+	recipes := testRecipes()
+	recipes.Elem(0, 3).Set("NaN")
+	p, err = Generate(testScores(), recipes, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range p.Days {
+		if d.RecipeID == "1" {
+			t.Errorf("Recipe without kcal is planned, got '%v'", planned(p))
+		}
+	}
+
+	config.Nutrition = map[string]Bounds{"nutrition_protein": {Min: 20}}
+	if _, err := Generate(testScores(), testRecipes(), config); err == nil {
+		t.Error("Expected an error for a missing nutrition column")
+	}
+}
+
+func TestMainIngredients(t *testing.T) {
+	recipes := dataframe.LoadRecords([][]string{
+		{"id", "title", "totalTime", "ingredient_kipdijfilet", "ingredient_kippenbouillon", "ingredient_rode_currypasta", "ingredient_varkensgehakt"},
+		{"1", "Kip", "30", "1", "0", "0", "0"},
+		{"2", "Curry", "30", "0", "1", "1", "0"},
+		{"3", "Gehaktballen", "30", "0", "0", "0", "1"},
+	})
+
+	candidates, err := candidates(map[string]float64{"1": 3, "2": 2, "3": 1}, recipes, DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"chicken"}, nil, {"pork"}}
+	for i, c := range candidates {
+		if !reflect.DeepEqual(c.main, want[i]) {
+			t.Errorf("Main ingredients of recipe %s are incorrect, got '%v', want '%v'", c.id, c.main, want[i])
+		}
+	}
+}
+
+func TestGenerateIDColumn(t *testing.T) {
+	config := DefaultConfig()
+	config.Days = 3
+	config.ReuseBonus = 0
+
+	//the id column is found by name
+	recipes := testRecipes()
+	names := append(recipes.Names()[1:], "id")
+	p, err := Generate(testScores(), recipes.Select(names), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := planned(p); !reflect.DeepEqual(got, []string{"1", "4", "5"}) {
+		t.Errorf("Plan with the id column last is incorrect, got '%v', want '%v'", got, []string{"1", "4", "5"})
+	}
+
+	if _, err := Generate(testScores(), recipes.Drop("id"), config); err == nil {
+		t.Error("Expected an error for recipes without id column")
+	}
+}
+
+func TestGenerateInfeasible(t *testing.T) {
+	config := DefaultConfig()
+
+	//only 4 dinners can be cooked without repeating chicken or pasta
+	if _, err := Generate(testScores(), testRecipes(), config); !errors.Is(err, ErrInfeasible) {
+		t.Errorf("Expected an infeasible plan, got '%v'", err)
+	}
+
+	//recipes without score are never planned
+	config.Days = 1
+	p, err := Generate(map[string]float64{"5": 1}, testRecipes(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(planned(p), []string{"5"}) {
+		t.Errorf("Plan is incorrect, got '%v', want '%v'", planned(p), []string{"5"})
+	}
+}
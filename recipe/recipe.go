@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/go-gota/gota/dataframe"
+	"github.com/go-gota/gota/series"
 	"github.com/gocolly/colly/v2"
 	"github.com/julienrbrt/ut_research_project/util"
 )
@@ -26,6 +27,38 @@ type Recipe struct {
 	WaitTime        int `json:"waitTime"`
 	ImageURL        string
 	URL             string `json:"href"`
+	//Servings is the number of servings of the recipe, 0 when unknown
+	Servings int
+	//Nutrition contains the value per serving of the nutrients (see Nutrients) given by the recipe
+	Nutrition map[string]float64
+}
+
+//Nutrients are the nutrients scraped per serving, saved in nutrition_<nutrient> columns (kcal, and grams otherwise)
+var Nutrients = []string{"kcal", "protein", "carbohydrates", "fat"}
+
+//nutritionProperties maps the schema.org nutrition properties of the recipes to their nutrient
+var nutritionProperties = map[string]string{
+	"calories":            "kcal",
+	"proteinContent":      "protein",
+	"carbohydrateContent": "carbohydrates",
+	"fatContent":          "fat",
+}
+
+//numberReg matches a decimal number, with a dot or a comma
+var numberReg = regexp.MustCompile(`\d+([.,]\d+)?`)
+
+//firstNumber returns the first number of a text (e.g. 520 of "520 kcal"), false when there is none
+func firstNumber(text string) (float64, bool) {
+	n := numberReg.FindString(text)
+	if n == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.Replace(n, ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return v, true
 }
 
 //Recipes contains a recipe list from AH
@@ -77,6 +110,29 @@ func (r *Recipe) ScrapeAH(recipeURL string) {
 		})
 	})
 
+	//get servings
+	c.OnHTML("[itemprop=\"recipeYield\"]", func(e *colly.HTMLElement) {
+		if v, ok := firstNumber(e.Text); ok {
+			r.Servings = int(v)
+		}
+	})
+
+	//get nutrition per serving
+	c.OnHTML("[itemprop=\"nutrition\"]", func(e *colly.HTMLElement) {
+		e.ForEach("[itemprop]", func(_ int, i *colly.HTMLElement) {
+			nutrient, ok := nutritionProperties[i.Attr("itemprop")]
+			if !ok {
+				return
+			}
+			if v, ok := firstNumber(i.Text); ok {
+				if r.Nutrition == nil {
+					r.Nutrition = make(map[string]float64)
+				}
+				r.Nutrition[nutrient] = v
+			}
+		})
+	})
+
 	//get image
 	c.OnHTML("li.responsive-image", func(e *colly.HTMLElement) {
 		r.ImageURL, _ = e.DOM.Attr("data-phone-src")
@@ -140,6 +196,9 @@ func (recipes *Recipes) transformToDF() (dataframe.DataFrame, error) {
 	}
 
 	//append to headers
+	for _, n := range Nutrients {
+		headers = append(headers, "nutrition_"+n)
+	}
 	headers = append(headers, tags...)
 	headers = append(headers, ingredients...)
 
@@ -153,6 +212,15 @@ func (recipes *Recipes) transformToDF() (dataframe.DataFrame, error) {
 			recipe.URL,
 		}
 
+		//add nutrition, empty when unknown
+		for _, n := range Nutrients {
+			if v, ok := recipe.Nutrition[n]; ok {
+				data = append(data, strconv.FormatFloat(v, 'f', -1, 64))
+			} else {
+				data = append(data, "")
+			}
+		}
+
 		//clean ingredients and tags
 		recipe.Tags, err = cleanIngredientsAndTags(recipe.Tags, false)
 		if err != nil {
@@ -280,14 +348,40 @@ func cleanIngredientsAndTags(data []string, isIngredient bool) ([]string, error)
 	return data, nil
 }
 
+//ingredientsToDF converts the ingredient lines of a list of recipes as a dataframe with a row per line
+//recipe_id is the id of the recipe in transformToDF, line the quantity and ingredient as written in the recipe, ingredient the ingredient name
+//and servings the number of servings of the recipe the quantities are for, empty when unknown
+func (recipes *Recipes) ingredientsToDF() dataframe.DataFrame {
+	records := [][]string{{"recipe_id", "line", "ingredient", "servings"}}
+	for i, recipe := range recipes.Recipes {
+		servings := ""
+		if recipe.Servings > 0 {
+			servings = strconv.Itoa(recipe.Servings)
+		}
+		for j, line := range recipe.Ingredients {
+			ingredient := ""
+			if j < len(recipe.IngredientsOnly) {
+				ingredient = recipe.IngredientsOnly[j]
+			}
+			records = append(records, []string{strconv.Itoa(i + 1), strings.TrimSpace(line), ingredient, servings})
+		}
+	}
+
+	return dataframe.LoadRecords(records, dataframe.DetectTypes(false), dataframe.DefaultType(series.String))
+}
+
 //RecipesData of N recipes from internet
-func RecipesData(n int, csvPath string) (dataframe.DataFrame, error) {
+//the ingredient lines of the recipes, used for shopping lists, are saved in csvPathIngredients
+func RecipesData(n int, csvPath, csvPathIngredients string) (dataframe.DataFrame, error) {
 	//scrape recipes
 	recipes, err := ScrapeNAH(n)
 	if err != nil {
 		return dataframe.DataFrame{}, err
 	}
 
+	//keep ingredient lines before they are cleaned
+	ingredients := recipes.ingredientsToDF()
+
 	//processing and load data
 	df, err := recipes.transformToDF()
 	if err != nil {
@@ -301,5 +395,12 @@ func RecipesData(n int, csvPath string) (dataframe.DataFrame, error) {
 		}
 	}
 
+	if csvPathIngredients != "" {
+		//save ingredients csv
+		if err := util.WriteCSV(ingredients, csvPathIngredients); err != nil {
+			return dataframe.DataFrame{}, err
+		}
+	}
+
 	return df, nil
 }
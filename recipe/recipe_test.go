@@ -54,3 +54,61 @@ func TestScrapeNAH(t *testing.T) {
 		t.Errorf("The number of recipes is incorrect, got '%d', want '%d'", len(recipes.Recipes), expectedRecipesLength)
 	}
 }
+
+//TestIngredientsToDF tests ingredientsToDF
+func TestIngredientsToDF(t *testing.T) {
+	recipes := Recipes{Recipes: []Recipe{
+		{Ingredients: []string{"400 g", " 2 el"}, IngredientsOnly: []string{"spaghetti", "olijfolie"}, Servings: 2},
+		{Ingredients: []string{"1"}, IngredientsOnly: []string{"ui"}},
+	}}
+
+	records := recipes.ingredientsToDF().Records()
+	expected := [][]string{
+		{"recipe_id", "line", "ingredient", "servings"},
+		{"1", "400 g", "spaghetti", "2"},
+		{"1", "2 el", "olijfolie", "2"},
+		{"2", "1", "ui", ""},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Ingredients are incorrect, got '%v', want '%v'", records, expected)
+	}
+	for i := range expected {
+		for j := range expected[i] {
+			if records[i][j] != expected[i][j] {
+				t.Errorf("Ingredients are incorrect, got '%v', want '%v'", records[i], expected[i])
+			}
+		}
+	}
+}
+
+//TestFirstNumber tests firstNumber
+func TestFirstNumber(t *testing.T) {
+	tests := map[string]float64{"520 kcal": 520, "eiwit 25,5 g": 25.5, "4.2g": 4.2}
+	for text, want := range tests {
+		if got, ok := firstNumber(text); !ok || got != want {
+			t.Errorf("Number of '%s' is incorrect, got '%v', want '%v'", text, got, want)
+		}
+	}
+	if _, ok := firstNumber("onbekend"); ok {
+		t.Error("Expected no number")
+	}
+}
+
+//TestTransformToDFNutrition tests the nutrition columns of transformToDF
+func TestTransformToDFNutrition(t *testing.T) {
+	recipes := Recipes{Recipes: []Recipe{
+		{Title: "Pasta", Nutrition: map[string]float64{"kcal": 520, "protein": 25.5}},
+		{Title: "Salade"},
+	}}
+
+	df, err := recipes.transformToDF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kcal := df.Col("nutrition_kcal").Records(); kcal[0] != "520" || kcal[1] != "NaN" {
+		t.Errorf("Kcal are incorrect, got '%v', want '[520 NaN]'", kcal)
+	}
+	if protein := df.Col("nutrition_protein").Elem(0).Float(); protein != 25.5 {
+		t.Errorf("Protein is incorrect, got '%v', want '%v'", protein, 25.5)
+	}
+}
@@ -4,97 +4,79 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"reflect"
-	"runtime"
 	"strconv"
 
 	"github.com/go-gota/gota/dataframe"
 	"github.com/olekukonko/tablewriter"
 	"github.com/zhenghaoz/gorse/base"
 	"github.com/zhenghaoz/gorse/core"
-	"github.com/zhenghaoz/gorse/model"
 )
 
-var models = []core.ModelInterface{
-	//BaseLine
-	model.NewBaseLine(base.Params{
-		base.NEpochs: 150,
-		base.Lr:      0.1,
-		base.Reg:     0.5,
-	}),
-	// SlopOne
-	model.NewSlopOne(nil),
-	// CoClustering
-	model.NewCoClustering(base.Params{
-		base.NEpochs:       150,
-		base.NUserClusters: 10,
-		base.NItemClusters: 10,
-	}),
-	// SVD
-	model.NewSVD(base.Params{
-		base.NEpochs:    500,
-		base.Reg:        0.005,
-		base.Lr:         0.005,
-		base.NFactors:   10,
-		base.InitMean:   0,
-		base.InitStdDev: 0.01,
-	}),
-	//BRP
-	model.NewBPR(base.Params{
-		base.NEpochs:    150,
-		base.NFactors:   50,
-		base.Reg:        0.005,
-		base.Lr:         0.01,
-		base.InitMean:   0,
-		base.InitStdDev: 0.001,
-	}),
-	// KNN
-	model.NewKNN(base.Params{
-		base.NEpochs:    150,
-		base.Type:       base.Baseline,
-		base.UserBased:  true,
-		base.Similarity: base.MSD,
-		base.K:          80,
-		base.Lr:         0.005,
-		base.Reg:        0.02,
-	}),
-}
-
 //WithCollaborativeFiltering recommends recipes using collaborative filtering
-func WithCollaborativeFiltering(userID, nbRecipes int, neighborsUsers, orders, recipes dataframe.DataFrame) error {
+//models are the models to train and compare (see Registry)
+//split defines how orders are divided for training and evaluating the models
+//filter excludes the recipes the user must not be recommended (see AllergenMapping), nil to allow all recipes
+//reranker adapts the ranking to the context of the request (see Context), nil to keep the ranking
+//weight weights the neighbors in the sellability (see DistanceWeight), nil to weight them equally
+//lambda trades off the ranking against the appeal of the recipes to the neighbors (see SellabilityReranker), 1 to keep the ranking
+func WithCollaborativeFiltering(userID, nbRecipes int, models []NamedModel, split Splitter, filter RecipeFilter, reranker Reranker, weight NeighborWeight, lambda float64, neighborsUsers, orders, recipes dataframe.DataFrame) error {
 	log.Printf("(Collaborative Filtering) Recommending Recipes for user %d", userID)
 
+	//orders without rating are implicit feedback, models are only evaluated on ranking
+	implicit := isImplicit(orders)
+	if implicit {
+		split = ImplicitSplit(split)
+	}
+
 	//load dataset
-	data := core.NewDataSet(orders.Col("user_id").Records(), orders.Col("recipe_id").Records(), orders.Col("rating").Float())
+	data := ordersDataSet(orders)
 	//split dataset
-	train, test := core.Split(data, 0.2)
+	train, test, err := split(data, orders)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := NewRecipeProfiles(recipes)
+	if err != nil {
+		return err
+	}
 
 	//create model
 	lines := make([][]string, 0)
-	for _, m := range models {
+	for _, nm := range models {
+		m := nm.Model
 		//fit model
 		m.Fit(train, nil)
 		//evaluate model
 		scoresRanking := core.EvaluateRank(m, test, train, nbRecipes, core.Precision, core.Recall)
-		scoresRating := core.EvaluateRating(m, test, core.RMSE)
+		rmse := "-"
+		if !implicit {
+			rmse = fmt.Sprintf("%.5f", core.EvaluateRating(m, test, core.RMSE)[0])
+		}
+		//recommendations of the neighbors by the same model
+		recommender := cachedRecommender(CollaborativeRecommender(nbRecipes, m, data, train))
+		//prefer the recipes appealing to the neighbors
+		modelReranker, err := localReranker(reranker, lambda, neighborsUsers, recipes, recommender, profiles, weight)
+		if err != nil {
+			return err
+		}
+
 		//generate recommendations for user
-		//get all items in the full dataset
-		items := core.Items(data)
-		//get user ratings in the training set
-		excludeItems := train.User(strconv.Itoa(userID))
-		//get top recommended items (excluding rated items)
-		recommendItems, _ := core.Top(items, strconv.Itoa(userID), nbRecipes, excludeItems, m)
+		recommendItems := recommendedCollaborativeFiltering(strconv.Itoa(userID), nbRecipes, m, data, train, filter, modelReranker)
 
 		//calculate sellability
-		sellability := MeasureCollaborativeSellability(userID, nbRecipes, recommendItems, m, data, train, test, neighborsUsers, recipes)
+		sellability, err := MeasureSellability(recommendItems, neighborsUsers, recommender, profiles, weight)
+		if err != nil {
+			return err
+		}
 
 		//fill in table with scores and recommended items
 		lines = append(lines, []string{
-			fmt.Sprint(reflect.TypeOf(m)),         //model
-			fmt.Sprintf("%.5f", scoresRanking[0]), //precision@nbRecipes
-			fmt.Sprintf("%.5f", scoresRanking[1]), //recall@NbRecipes
-			fmt.Sprintf("%.5f", scoresRating[0]),  //rmse@nbRecipes
-			fmt.Sprintf("%.5f", sellability),      //sellability@km
+			nm.Name,                                //model
+			fmt.Sprintf("%.5f", scoresRanking[0]),  //precision@nbRecipes
+			fmt.Sprintf("%.5f", scoresRanking[1]),  //recall@NbRecipes
+			rmse,                                   //rmse@nbRecipes
+			fmt.Sprintf("%.5f", sellability.Score), //sellability@km
 			fmt.Sprintf("%v", recommendItems),
 		})
 	}
@@ -111,99 +93,57 @@ func WithCollaborativeFiltering(userID, nbRecipes int, neighborsUsers, orders, r
 	return nil
 }
 
-//BestHyperParametersKNN test best fitting parameters of the KNN model
-func BestHyperParametersKNN(nbRecipes int, orders dataframe.DataFrame) {
-	//load dataset
-	data := core.NewDataSet(orders.Col("user_id").Records(), orders.Col("recipe_id").Records(), orders.Col("rating").Float())
-
-	cv := core.GridSearchCV(model.NewKNN(nil), data, core.ParameterGrid{
-		base.Lr:         {0.005, 0.05, 0.1},
-		base.Reg:        {0.005, 0.02, 0.5},
-		base.NEpochs:    {50},
-		base.Type:       {base.Baseline},
-		base.Similarity: {base.Cosine, base.MSD},
-		base.K:          {10, 40, 80},
-	}, core.NewKFoldSplitter(5), 0, &base.RuntimeOptions{Verbose: false, FitJobs: 1, CVJobs: runtime.NumCPU()},
-		core.NewRankEvaluator(nbRecipes, core.Precision, core.Recall), core.NewRatingEvaluator(core.RMSE))
-	fmt.Println("=== Precision@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[0].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[0].BestParams)
-	fmt.Println("=== Recall@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[1].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[1].BestParams)
-	fmt.Println("=== RMSE@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[2].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[2].BestParams)
-}
+//recommendedCollaborativeFiltering returns the top recommended items of a user
+//items rated by the user in the training set and recipes not allowed by filter are excluded
+//with a reranker, all items are ranked by their prediction adjusted to the context
+func recommendedCollaborativeFiltering(userID string, nbRecipes int, m core.ModelInterface, data *core.DataSet, train core.DataSetInterface, filter RecipeFilter, reranker Reranker) []string {
+	//get all allowed items in the full dataset
+	items := core.Items(data)
+	for id := range items {
+		if !filter.allows(id) {
+			delete(items, id)
+		}
+	}
+	//get user ratings in the training set, none when the user has no order in it (the implicit train set has its own users)
+	excludeItems := &base.MarginalSubSet{}
+	if train.UserIndexer().ToIndex(userID) != base.NotId {
+		excludeItems = train.User(userID)
+	}
+	if reranker == nil {
+		//get top recommended items (excluding rated items)
+		recommendItems, _ := core.Top(items, userID, nbRecipes, excludeItems, m)
+		return recommendItems
+	}
 
-//BestHyperParametersBaseLine test best fitting parameters of the BaseLine model
-func BestHyperParametersBaseLine(nbRecipes int, orders dataframe.DataFrame) {
-	//load dataset
-	data := core.NewDataSet(orders.Col("user_id").Records(), orders.Col("recipe_id").Records(), orders.Col("rating").Float())
-
-	cv := core.GridSearchCV(model.NewKNN(nil), data, core.ParameterGrid{
-		base.Lr:      {0.005, 0.05, 0.1},
-		base.Reg:     {0.005, 0.02, 0.5},
-		base.NEpochs: {50},
-	}, core.NewKFoldSplitter(5), 0, &base.RuntimeOptions{Verbose: false, FitJobs: 1, CVJobs: runtime.NumCPU()},
-		core.NewRankEvaluator(nbRecipes, core.Precision, core.Recall), core.NewRatingEvaluator(core.RMSE))
-	fmt.Println("=== Precision@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[0].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[0].BestParams)
-	fmt.Println("=== Recall@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[1].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[1].BestParams)
-	fmt.Println("=== RMSE@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[2].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[2].BestParams)
-}
+	//rank all items (excluding rated items) in the context of the request
+	ids, scores := core.Top(items, userID, len(items), excludeItems, m)
+	candidates := make([]kv, len(ids))
+	for i := range ids {
+		candidates[i] = kv{ids[i], scores[i]}
+	}
+	rerank(candidates, reranker)
+	if len(candidates) > nbRecipes {
+		candidates = candidates[:nbRecipes]
+	}
+	recommendItems := make([]string, len(candidates))
+	for i, c := range candidates {
+		recommendItems[i] = c.Key
+	}
 
-//BestHyperParametersBPR test best fitting parameters of the BPR model
-func BestHyperParametersBPR(nbRecipes int, orders dataframe.DataFrame) {
-	//load dataset
-	data := core.NewDataSet(orders.Col("user_id").Records(), orders.Col("recipe_id").Records(), orders.Col("rating").Float())
-
-	cv := core.GridSearchCV(model.NewBPR(nil), data, core.ParameterGrid{
-		base.NFactors:   {5, 10, 50},
-		base.Reg:        {0.005, 0.01, 0.5},
-		base.Lr:         {0.01, 0.05, 0.1},
-		base.NEpochs:    {50},
-		base.InitMean:   {0},
-		base.InitStdDev: {0.001},
-	}, core.NewKFoldSplitter(5), 0, &base.RuntimeOptions{Verbose: false, FitJobs: 1, CVJobs: runtime.NumCPU()},
-		core.NewRankEvaluator(nbRecipes, core.Precision, core.Recall), core.NewRatingEvaluator(core.RMSE))
-	fmt.Println("=== Precision@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[0].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[0].BestParams)
-	fmt.Println("=== Recall@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[1].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[1].BestParams)
-	fmt.Println("=== RMSE@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[2].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[2].BestParams)
+	return recommendItems
 }
 
-//BestHyperParametersSVD test best fitting parameters of the SVD model
-func BestHyperParametersSVD(nbRecipes int, orders dataframe.DataFrame) {
-	//load dataset
-	data := core.NewDataSet(orders.Col("user_id").Records(), orders.Col("recipe_id").Records(), orders.Col("rating").Float())
-
-	cv := core.GridSearchCV(model.NewSVD(nil), data, core.ParameterGrid{
-		base.NFactors:   {5, 10, 50, 100},
-		base.Reg:        {0.005, 0.2, 0.5},
-		base.Lr:         {0.005, 0.05, 0.1},
-		base.NEpochs:    {50},
-		base.InitMean:   {0},
-		base.InitStdDev: {0.001, 0.1},
-	}, core.NewKFoldSplitter(5), 0, &base.RuntimeOptions{Verbose: false, FitJobs: 1, CVJobs: runtime.NumCPU()},
-		core.NewRankEvaluator(nbRecipes, core.Precision, core.Recall), core.NewRatingEvaluator(core.RMSE))
-	fmt.Println("=== Precision@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[0].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[0].BestParams)
-	fmt.Println("=== Recall@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[1].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[1].BestParams)
-	fmt.Println("=== RMSE@nbRecipes")
-	fmt.Printf("The best score is: %.5f\n", cv[2].BestScore)
-	fmt.Printf("The best params is: %v\n", cv[2].BestParams)
+//UserScores fits a model on all orders and returns the predicted score of the recipes for a user
+//recipes not allowed by filter are excluded
+func UserScores(userID int, m core.ModelInterface, orders, recipes dataframe.DataFrame, filter RecipeFilter) map[string]float64 {
+	m.Fit(ordersDataSet(orders), nil)
+
+	scores := make(map[string]float64)
+	for _, id := range recipes.Col("id").Records() {
+		if filter.allows(id) {
+			scores[id] = m.Predict(strconv.Itoa(userID), id)
+		}
+	}
+
+	return scores
 }
@@ -6,6 +6,7 @@ import (
 	"math"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/go-gota/gota/dataframe"
@@ -134,7 +135,36 @@ func recipeSimilarityMatrix(recipes dataframe.DataFrame) (*mat.Dense, error) {
 	return matrix, nil
 }
 
-func recommendedContentFiltering(userID, nbRecipes, nbTags int, orders, recipes dataframe.DataFrame) ([]int, error) {
+//recipesMatrixPath is the precomputed cosine similarity matrix of the recipes
+const recipesMatrixPath = "data/recipes_matrix.csv"
+
+//contentSimilarity returns the cosine similarity matrix of the recipes
+//the precomputed matrix is used when it matches the recipes, otherwise the matrix is calculated
+func contentSimilarity(recipes dataframe.DataFrame) (mat.Matrix, error) {
+	if _, err := os.Stat(recipesMatrixPath); err == nil {
+		sim := matrix{util.LoadCSV(recipesMatrixPath)}
+		if r, c := sim.Dims(); r == recipes.Nrow() && c == recipes.Nrow() {
+			return sim, nil
+		}
+	}
+
+	return recipeSimilarityMatrix(recipes)
+}
+
+//recommendedContentFiltering returns the recipes id most similar to the best rated recipes of the user having its preferred tags
+//recipes not allowed by filter are never recommended, the similar recipes are sorted by reranker when it is not nil
+func recommendedContentFiltering(userID, nbRecipes, nbTags int, filter RecipeFilter, reranker Reranker, orders, recipes dataframe.DataFrame) ([]int, error) {
+	//calculate cosine similarity
+	sim, err := contentSimilarity(recipes)
+	if err != nil {
+		return nil, err
+	}
+
+	return similarRecipes(userID, nbRecipes, nbTags, filter, reranker, sim, orders, recipes)
+}
+
+//similarRecipes returns the recommendations of recommendedContentFiltering from the cosine similarity matrix of the recipes
+func similarRecipes(userID, nbRecipes, nbTags int, filter RecipeFilter, reranker Reranker, sim mat.Matrix, orders, recipes dataframe.DataFrame) ([]int, error) {
 	//user profile
 	orders = userProfileOrder(userID, orders, recipes)
 	log.Printf("User %d has made %d orders with a (normalized) average rating of %.2f per order\n", userID, orders.Nrow(), orders.Col("rating").Mean())
@@ -179,23 +209,50 @@ func recommendedContentFiltering(userID, nbRecipes, nbTags int, orders, recipes
 		ids = ids[:nbTags]
 	}
 
-	//calculate cosine similarity
-	// sim, err := recipeSimilarityMatrix(recipes)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	sim := matrix{util.LoadCSV("data/recipes_matrix.csv")}
+	//the rows of the matrix are the recipes in the order of the dataset
+	recipeIDs, err := recipes.Col("id").Int()
+	if err != nil {
+		return nil, err
+	}
+	rows := make(map[int]int)
+	for i, id := range recipeIDs {
+		rows[id] = i
+	}
 
-	var recommendItems []int
+	var candidates []kv
+	recommended := make(map[int]bool)
 	for _, r := range ids {
-		//r-1 because id starts at 1 but matrix at 0
-		bestMatch := mat.Row(nil, r-1, sim)
+		row, ok := rows[r]
+		if !ok {
+			continue
+		}
+		bestMatch := mat.Row(nil, row, sim)
 
 		bestIndex := argsort.SortSlice(bestMatch, func(i, j int) bool {
 			return bestMatch[i] > bestMatch[j]
 		})
 
-		recommendItems = append(recommendItems, bestIndex[1:nbTags]...)
+		//keep the nbTags-1 most similar allowed recipes, excluding the recipe itself
+		count := 0
+		for _, i := range bestIndex {
+			if count >= nbTags-1 {
+				break
+			}
+			id := recipeIDs[i]
+			if id == r || recommended[id] || !filter.allows(strconv.Itoa(id)) {
+				continue
+			}
+			recommended[id] = true
+			candidates = append(candidates, kv{strconv.Itoa(id), bestMatch[i]})
+			count++
+		}
+	}
+
+	//sort the similar recipes in the context of the request
+	rerank(candidates, reranker)
+	recommendItems := make([]int, len(candidates))
+	for i, c := range candidates {
+		recommendItems[i], _ = strconv.Atoi(c.Key)
 	}
 
 	//set maximum recommended recipes
@@ -208,25 +265,53 @@ func recommendedContentFiltering(userID, nbRecipes, nbTags int, orders, recipes
 
 //WithContentFiltering recommends recipes using content filtering
 //returns the recommended recipes_id
-func WithContentFiltering(userID, nbRecipes, nbTags int, neighborsUsers, orders, recipes dataframe.DataFrame) error {
+//filter excludes the recipes the user must not be recommended (see AllergenMapping), nil to allow all recipes
+//reranker adapts the ranking to the context of the request (see Context), nil to keep the ranking
+//weight weights the neighbors in the sellability (see DistanceWeight), nil to weight them equally
+//lambda trades off the ranking against the appeal of the recipes to the neighbors (see SellabilityReranker), 1 to keep the ranking
+func WithContentFiltering(userID, nbRecipes, nbTags int, filter RecipeFilter, reranker Reranker, weight NeighborWeight, lambda float64, neighborsUsers, orders, recipes dataframe.DataFrame) error {
 	log.Printf("(Content Filtering) Recommending Recipes for user %d", userID)
 
+	profiles, err := NewRecipeProfiles(recipes)
+	if err != nil {
+		return err
+	}
+	//recommendations of the neighbors
+	recommender, err := ContentRecommender(nbRecipes, nbTags, orders, recipes)
+	if err != nil {
+		return err
+	}
+	recommender = cachedRecommender(recommender)
+
+	//prefer the recipes appealing to the neighbors
+	reranker, err = localReranker(reranker, lambda, neighborsUsers, recipes, recommender, profiles, weight)
+	if err != nil {
+		return err
+	}
+
 	//calculate recommended recipes
-	recommendItems, err := recommendedContentFiltering(userID, nbRecipes, nbTags, orders, recipes)
+	recommendItems, err := recommendedContentFiltering(userID, nbRecipes, nbTags, filter, reranker, orders, recipes)
 	if err != nil {
 		return err
 	}
 
 	//calculate sellability
-	sellability := MeasureContentSellability(userID, nbRecipes, nbTags, recommendItems, neighborsUsers, orders, recipes)
+	recommendations := make([]string, len(recommendItems))
+	for i, r := range recommendItems {
+		recommendations[i] = strconv.Itoa(r)
+	}
+	sellability, err := MeasureSellability(recommendations, neighborsUsers, recommender, profiles, weight)
+	if err != nil {
+		return err
+	}
 
 	//fill in table with scores and recommended items
 	lines := make([][]string, 0)
 	lines = append(lines, []string{
-		fmt.Sprint("Content Filtering"),  //model
-		fmt.Sprintf("%.5f", 0.0),         //precision@nbRecipes
-		fmt.Sprintf("%.5f", 0.0),         //recall@NbRecipes
-		fmt.Sprintf("%.5f", sellability), //sellability@km
+		fmt.Sprint("Content Filtering"),        //model
+		fmt.Sprintf("%.5f", 0.0),               //precision@nbRecipes
+		fmt.Sprintf("%.5f", 0.0),               //recall@NbRecipes
+		fmt.Sprintf("%.5f", sellability.Score), //sellability@km
 		fmt.Sprintf("%v", recommendItems),
 	})
 
@@ -0,0 +1,242 @@
+package recommend
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"gonum.org/v1/gonum/stat"
+)
+
+//Context is the situation in which a user asks for recommendations
+type Context struct {
+	//MaxTime is the maximum total time of the recipes in minutes, 0 without limit
+	MaxTime int
+	//Time is when the user asks for recommendations, it defines the meal and if it is a weekday, zero when unknown
+	Time time.Time
+	//People is the number of people to cook for, 0 when unknown
+	People int
+}
+
+//Meal returns the meal of the context time: breakfast, lunch, dinner or snack
+func (c Context) Meal() string {
+	switch h := c.Time.Hour(); {
+	case h >= 5 && h < 11:
+		return "breakfast"
+	case h >= 11 && h < 15:
+		return "lunch"
+	case h >= 15 && h < 22:
+		return "dinner"
+	default:
+		return "snack"
+	}
+}
+
+//IsWeekend returns if the context time is on a saturday or a sunday
+func (c Context) IsWeekend() bool {
+	return c.Time.Weekday() == time.Saturday || c.Time.Weekday() == time.Sunday
+}
+
+//situations returns the situations of the context used by the tag boosts
+func (c Context) situations(config *ContextConfig) []string {
+	var situations []string
+	if !c.Time.IsZero() {
+		if c.IsWeekend() {
+			situations = append(situations, "weekend")
+		} else {
+			situations = append(situations, "weekday")
+		}
+		situations = append(situations, c.Meal())
+	}
+	if config.GroupSize > 0 && c.People >= config.GroupSize {
+		situations = append(situations, "group")
+	}
+
+	return situations
+}
+
+//ContextConfig defines how the context re-ranks the recommended recipes
+type ContextConfig struct {
+	//WeekdayMinutes and WeekendMinutes are the time available to cook each meal
+	WeekdayMinutes map[string]int
+	WeekendMinutes map[string]int
+	//TimePenalty is the score lost by a recipe taking twice the available time, in standard deviations of the recommendation scores
+	TimePenalty float64
+	//GroupSize is the number of people from which a meal is cooked for a group
+	GroupSize int
+	//TagBoosts contains the score gained by the recipes having a tag in a situation (weekday, weekend, a meal or group)
+	TagBoosts map[string]map[string]float64
+}
+
+//DefaultContextConfig returns the context configuration used when none is given
+//users have little time on weekday evenings and cook longer recipes during the weekend
+func DefaultContextConfig() *ContextConfig {
+	return &ContextConfig{
+		WeekdayMinutes: map[string]int{"breakfast": 10, "lunch": 15, "dinner": 30, "snack": 15},
+		WeekendMinutes: map[string]int{"breakfast": 30, "lunch": 30, "dinner": 60, "snack": 20},
+		TimePenalty:    1,
+		GroupSize:      6,
+		TagBoosts: map[string]map[string]float64{
+			"weekday": {"tag_snel": 0.5},
+			"weekend": {"tag_feestelijk": 0.3},
+			"group":   {"tag_ovenschotel": 0.3, "tag_feestelijk": 0.3},
+		},
+	}
+}
+
+//Reranker returns the bonus of a recipe in the current context, in standard deviations of the recommendation scores
+type Reranker func(recipeID string) float64
+
+//recipesTime returns the total time of the recipes by id, 0 when unknown
+func recipesTime(recipes dataframe.DataFrame) (map[string]int, error) {
+	if !hasColumn(recipes, "id") || !hasColumn(recipes, "totalTime") {
+		return nil, errors.New("recipes must have id and totalTime columns")
+	}
+
+	ids := recipes.Col("id").Records()
+	times := recipes.Col("totalTime").Records()
+	recipesTime := make(map[string]int)
+	for i, id := range ids {
+		t, err := strconv.Atoi(times[i])
+		if err != nil {
+			continue
+		}
+		recipesTime[id] = t
+	}
+
+	return recipesTime, nil
+}
+
+//Filter returns the filter of the recipes taking at most the maximum time, nil without maximum time
+//recipes with an unknown total time are allowed
+func (c Context) Filter(recipes dataframe.DataFrame) (RecipeFilter, error) {
+	if c.MaxTime <= 0 {
+		return nil, nil
+	}
+
+	times, err := recipesTime(recipes)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(recipeID string) bool {
+		return times[recipeID] <= c.MaxTime
+	}, nil
+}
+
+//Reranker returns the reranker of the recipes in the context, nil when the context does not change the ranking
+//recipes taking longer than the time available for the meal lose score and recipes with tags suited to the context gain score
+func (c Context) Reranker(config *ContextConfig, recipes dataframe.DataFrame) (Reranker, error) {
+	situations := c.situations(config)
+	if len(situations) == 0 {
+		return nil, nil
+	}
+
+	times, err := recipesTime(recipes)
+	if err != nil {
+		return nil, err
+	}
+
+	//time available to cook the meal
+	available := 0
+	if !c.Time.IsZero() {
+		if c.IsWeekend() {
+			available = config.WeekendMinutes[c.Meal()]
+		} else {
+			available = config.WeekdayMinutes[c.Meal()]
+		}
+	}
+
+	//bonus of the tags suited to the situations
+	bonus := make(map[string]float64)
+	for _, s := range situations {
+		for tag, b := range config.TagBoosts[s] {
+			if !hasColumn(recipes, tag) {
+				continue
+			}
+			for i, v := range recipes.Col(tag).Records() {
+				if v == "1" {
+					bonus[recipes.Col("id").Elem(i).String()] += b
+				}
+			}
+		}
+	}
+
+	return func(recipeID string) float64 {
+		b := bonus[recipeID]
+		if t := times[recipeID]; available > 0 && t > available {
+			b -= config.TimePenalty * (float64(t)/float64(available) - 1)
+		}
+		return b
+	}, nil
+}
+
+//AllFilters returns the filter allowing the recipes allowed by all filters, nil filters are ignored
+func AllFilters(filters ...RecipeFilter) RecipeFilter {
+	var all []RecipeFilter
+	for _, f := range filters {
+		if f != nil {
+			all = append(all, f)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+
+	return func(recipeID string) bool {
+		for _, f := range all {
+			if !f(recipeID) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+//AllRerankers returns the reranker adding the bonuses of all rerankers, nil rerankers are ignored
+func AllRerankers(rerankers ...Reranker) Reranker {
+	var all []Reranker
+	for _, r := range rerankers {
+		if r != nil {
+			all = append(all, r)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+
+	return func(recipeID string) float64 {
+		bonus := 0.0
+		for _, r := range all {
+			bonus += r(recipeID)
+		}
+		return bonus
+	}
+}
+
+//rerank sorts the candidates by their standardized score plus their bonus, a nil reranker keeps the order
+func rerank(candidates []kv, reranker Reranker) {
+	if reranker == nil || len(candidates) == 0 {
+		return
+	}
+
+	scores := make([]float64, len(candidates))
+	for i, c := range candidates {
+		scores[i] = c.Value
+	}
+	mean, std := stat.MeanStdDev(scores, nil)
+
+	adjusted := make(map[string]float64)
+	for _, c := range candidates {
+		z := 0.0
+		if std > 0 {
+			z = (c.Value - mean) / std
+		}
+		adjusted[c.Key] = z + reranker(c.Key)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return adjusted[candidates[i].Key] > adjusted[candidates[j].Key]
+	})
+}
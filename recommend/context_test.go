@@ -0,0 +1,106 @@
+package recommend
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestContextMeal(t *testing.T) {
+	tuesday := Context{Time: time.Date(2020, 6, 2, 18, 0, 0, 0, time.UTC)}
+	if tuesday.Meal() != "dinner" || tuesday.IsWeekend() {
+		t.Errorf("Context is incorrect, got '%s' weekend '%v', want 'dinner' weekend 'false'", tuesday.Meal(), tuesday.IsWeekend())
+	}
+
+	saturday := Context{Time: time.Date(2020, 6, 6, 9, 30, 0, 0, time.UTC)}
+	if saturday.Meal() != "breakfast" || !saturday.IsWeekend() {
+		t.Errorf("Context is incorrect, got '%s' weekend '%v', want 'breakfast' weekend 'true'", saturday.Meal(), saturday.IsWeekend())
+	}
+}
+
+func TestContextFilter(t *testing.T) {
+	recipes := testRecipes()
+
+	filter, err := Context{}.Filter(recipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter != nil {
+		t.Error("Context without maximum time should not filter recipes")
+	}
+
+	filter, err = Context{MaxTime: 25}.Filter(recipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]bool{"1": true, "2": false, "3": false, "4": true} {
+		if got := filter(id); got != want {
+			t.Errorf("Filter of recipe %s is incorrect, got '%v', want '%v'", id, got, want)
+		}
+	}
+
+	//restrictions and time are both enforced
+	filter = AllFilters(filter, nil, func(id string) bool { return id != "4" })
+	if filter("4") || !filter("1") {
+		t.Error("All filters should be enforced")
+	}
+}
+
+func TestContextReranker(t *testing.T) {
+	recipes := testRecipes()
+	config := DefaultContextConfig()
+
+	reranker, err := Context{}.Reranker(config, recipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reranker != nil {
+		t.Error("Unknown context should not change the ranking")
+	}
+
+	tests := []struct {
+		context Context
+		want    []string
+	}{
+		//quick dishes on a tuesday evening, the 45 minutes curry is last
+		{Context{Time: time.Date(2020, 6, 2, 18, 0, 0, 0, time.UTC)}, []string{"1", "3", "4", "2"}},
+		//there is time to cook on saturday evening
+		{Context{Time: time.Date(2020, 6, 6, 18, 0, 0, 0, time.UTC)}, []string{"2", "1", "3", "4"}},
+	}
+
+	for _, test := range tests {
+		reranker, err := test.context.Reranker(config, recipes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		candidates := []kv{{"2", 4}, {"1", 4}, {"3", 4}, {"4", 4}}
+		rerank(candidates, reranker)
+		var got []string
+		for _, c := range candidates {
+			got = append(got, c.Key)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Ranking on %s is incorrect, got '%v', want '%v'", test.context.Time.Weekday(), got, test.want)
+		}
+	}
+}
+
+func TestAllRerankers(t *testing.T) {
+	if AllRerankers(nil, nil) != nil {
+		t.Error("Rerankers should be nil when all rerankers are nil")
+	}
+
+	reranker := AllRerankers(func(string) float64 { return 0.5 }, nil, func(id string) float64 {
+		if id == "1" {
+			return 1
+		}
+		return 0
+	})
+	if got := reranker("1"); got != 1.5 {
+		t.Errorf("Bonus is incorrect, got '%f', want '%f'", got, 1.5)
+	}
+	if got := reranker("2"); got != 0.5 {
+		t.Errorf("Bonus is incorrect, got '%f', want '%f'", got, 0.5)
+	}
+}
@@ -0,0 +1,117 @@
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/core"
+)
+
+//Aggregation defines how the predicted ratings of the neighbors of a cook are aggregated per recipe
+type Aggregation string
+
+const (
+	//AggregateSum ranks the recipes by the sum of the predicted ratings of the neighbors
+	AggregateSum Aggregation = "sum"
+	//AggregateCoverage ranks the recipes by the number of neighbors having them in their top-k predicted recipes
+	AggregateCoverage Aggregation = "coverage"
+)
+
+//ParseAggregation returns the aggregation of a name, sum or coverage
+func ParseAggregation(name string) (Aggregation, error) {
+	switch a := Aggregation(name); a {
+	case AggregateSum, AggregateCoverage:
+		return a, nil
+	}
+
+	return "", fmt.Errorf("unknown aggregation %q, must be sum or coverage", name)
+}
+
+//CookRecommendation is a recipe to cook for the neighborhood
+type CookRecommendation struct {
+	RecipeID string
+	//Sum is the sum of the predicted ratings of the neighbors
+	Sum float64
+	//Covered is the number of neighbors having the recipe in their top-k predicted recipes
+	Covered int
+	//Neighbors is the number of neighbors counted, those without orders are skipped
+	Neighbors int
+}
+
+//RecommendForCook returns the recipes a cook should cook for its neighbors, the most appealing first
+//the model is fitted once on all orders and predicts the rating of each recipe by each neighbor,
+//recipes are ranked by the sum of these ratings or by their coverage of the neighbors top-k recipes (ties by sum)
+//neighbors unknown to the orders are skipped, the model has nothing to predict for them
+func RecommendForCook(cookID, nbRecipes int, m core.ModelInterface, aggregation Aggregation, topK int, filter RecipeFilter, neighborsUsers, orders, recipes dataframe.DataFrame) ([]CookRecommendation, error) {
+	if _, err := ParseAggregation(string(aggregation)); err != nil {
+		return nil, err
+	}
+	if aggregation == AggregateCoverage && topK <= 0 {
+		return nil, errors.New("top-k of the coverage must be positive")
+	}
+	if neighborsUsers.Nrow() == 0 {
+		return nil, nil
+	}
+
+	var ids []string
+	for _, id := range recipes.Col("id").Records() {
+		if filter.allows(id) {
+			ids = append(ids, id)
+		}
+	}
+
+	data := ordersDataSet(orders)
+	m.Fit(data, nil)
+
+	scores := make(map[string]*CookRecommendation, len(ids))
+	for _, id := range ids {
+		scores[id] = &CookRecommendation{RecipeID: id}
+	}
+
+	cook := strconv.Itoa(cookID)
+	counted := 0
+	for _, neighbor := range neighborsUsers.Col("id").Records() {
+		//the cook does not order its own meals
+		if neighbor == cook || data.UserIndexer().ToIndex(neighbor) == base.NotId {
+			continue
+		}
+		counted++
+
+		predicted := make([]kv, len(ids))
+		for i, id := range ids {
+			predicted[i] = kv{id, m.Predict(neighbor, id)}
+			scores[id].Sum += predicted[i].Value
+		}
+
+		if aggregation == AggregateCoverage {
+			sort.SliceStable(predicted, func(i, j int) bool {
+				return predicted[i].Value > predicted[j].Value
+			})
+			for i := 0; i < topK && i < len(predicted); i++ {
+				scores[predicted[i].Key].Covered++
+			}
+		}
+	}
+
+	recommendations := make([]CookRecommendation, len(ids))
+	for i, id := range ids {
+		recommendations[i] = *scores[id]
+		recommendations[i].Neighbors = counted
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if aggregation == AggregateCoverage && recommendations[i].Covered != recommendations[j].Covered {
+			return recommendations[i].Covered > recommendations[j].Covered
+		}
+		return recommendations[i].Sum > recommendations[j].Sum
+	})
+
+	if nbRecipes < len(recommendations) {
+		recommendations = recommendations[:nbRecipes]
+	}
+
+	return recommendations, nil
+}
@@ -0,0 +1,89 @@
+package recommend
+
+import (
+	"testing"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/zhenghaoz/gorse/base"
+)
+
+//tableModel predicts the ratings of a table, 0 when missing
+type tableModel struct {
+	*MatrixFactorization
+	ratings map[string]map[string]float64
+}
+
+func (m tableModel) Predict(userID, itemID string) float64 {
+	return m.ratings[userID][itemID]
+}
+
+func TestRecommendForCook(t *testing.T) {
+	m := tableModel{NewMatrixFactorization(base.Params{base.NFactors: 2}), map[string]map[string]float64{
+		"1": {"4": 100},
+		"2": {"1": 5, "2": 4.9},
+		"3": {"1": 5, "2": 4.9},
+		"4": {"3": 5, "2": 4.9},
+	}}
+	neighbors := dataframe.LoadRecords([][]string{{"id"}, {"1"}, {"2"}, {"3"}, {"4"}})
+	orders := testOrders().RBind(dataframe.LoadRecords([][]string{
+		{"user_id", "recipe_id", "rating", "date"},
+		{"4", "3", "4", "2020-05-01 12:00:00"},
+	}))
+
+	//recipe 2 is liked by all neighbors, the cook's own taste is ignored
+	recommendations, err := RecommendForCook(1, 2, m, AggregateSum, 0, nil, neighbors, orders, testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recommendations) != 2 || recommendations[0].RecipeID != "2" || recommendations[1].RecipeID != "1" {
+		t.Errorf("Sum recommendations are incorrect, got '%v', want recipes '[2 1]'", recommendations)
+	}
+
+	//recipe 1 is the favorite of two neighbors and recipe 3 of one
+	recommendations, err = RecommendForCook(1, 4, m, AggregateCoverage, 1, nil, neighbors, orders, testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1", "3", "2", "4"}
+	for i, r := range recommendations {
+		if r.RecipeID != want[i] {
+			t.Errorf("Coverage recommendations are incorrect, got '%v', want recipes '%v'", recommendations, want)
+			break
+		}
+	}
+	if recommendations[0].Covered != 2 {
+		t.Errorf("Coverage is incorrect, got '%d', want '%d'", recommendations[0].Covered, 2)
+	}
+
+	//filtered recipes are neither recommended nor counted in the top-k
+	filter := func(id string) bool { return id != "3" }
+	recommendations, err = RecommendForCook(1, 4, m, AggregateCoverage, 1, filter, neighbors, orders, testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recommendations) != 3 || recommendations[1].RecipeID != "2" || recommendations[1].Covered != 1 {
+		t.Errorf("Filtered recommendations are incorrect, got '%v'", recommendations)
+	}
+
+	if recommendations[0].Neighbors != 3 {
+		t.Errorf("Number of neighbors is incorrect, got '%d', want '%d'", recommendations[0].Neighbors, 3)
+	}
+
+	//neighbor 4 is skipped when it has no orders
+	recommendations, err = RecommendForCook(1, 4, m, AggregateCoverage, 1, nil, neighbors, testOrders(), testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recommendations[0].Neighbors != 2 || recommendations[1].RecipeID != "2" || recommendations[2].Covered != 0 {
+		t.Errorf("Recommendations skipping unknown neighbors are incorrect, got '%v'", recommendations)
+	}
+
+	recommendations, err = RecommendForCook(1, 4, m, AggregateSum, 0, nil, dataframe.DataFrame{}, orders, testRecipes())
+	if err != nil || len(recommendations) != 0 {
+		t.Errorf("Recommendations without neighbors are incorrect, got '%v' and '%v'", recommendations, err)
+	}
+
+	if _, err := RecommendForCook(1, 4, m, Aggregation("max"), 0, nil, neighbors, orders, testRecipes()); err == nil {
+		t.Error("Unknown aggregation should fail")
+	}
+}
@@ -0,0 +1,184 @@
+package recommend
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/zhenghaoz/gorse/core"
+)
+
+//DemandConfig contains the parameters of the demand forecast
+type DemandConfig struct {
+	//HorizonDays is the period of the forecast in days, 1 for the orders of tomorrow
+	HorizonDays float64
+	//Threshold is the predicted rating at which a neighbor ordering a meal has one chance out of two to choose the recipe
+	Threshold float64
+	//Slope is the steepness of the choice probability around the threshold
+	Slope float64
+	//Low and High are the quantiles of the number of portions giving the uncertainty of the forecast
+	Low  float64
+	High float64
+}
+
+//DefaultDemandConfig returns the parameters of the forecast of the orders of tomorrow with a 80% interval
+func DefaultDemandConfig() DemandConfig {
+	return DemandConfig{
+		HorizonDays: 1,
+		Threshold:   3.5,
+		Slope:       2,
+		Low:         0.1,
+		High:        0.9,
+	}
+}
+
+//Validate verifies the parameters
+func (c DemandConfig) Validate() error {
+	switch {
+	case c.HorizonDays <= 0:
+		return errors.New("forecast horizon must be positive")
+	case c.Slope < 0:
+		return errors.New("choice slope must not be negative")
+	case c.Low < 0 || c.High > 1 || c.Low > c.High:
+		return errors.New("quantiles must verify 0 <= low <= high <= 1")
+	}
+
+	return nil
+}
+
+//NeighborDemand is the forecast of the order of a neighbor
+type NeighborDemand struct {
+	UserID string
+	//Rating is the predicted rating of the recipe by the neighbor
+	Rating float64
+	//Probability is the probability that the neighbor orders a portion in the horizon
+	Probability float64
+}
+
+//Demand is the forecast of the number of portions of a recipe ordered by neighbors
+type Demand struct {
+	RecipeID  string
+	Neighbors int
+	//Expected is the expected number of portions and StdDev its standard deviation
+	Expected float64
+	StdDev   float64
+	//Low and High are the quantiles of the number of portions (e.g. a 80% interval)
+	Low  int
+	High int
+	//PerNeighbor contains the forecast per neighbor, from the most likely to order
+	PerNeighbor []NeighborDemand
+}
+
+//DemandForecaster forecasts the portions of a recipe ordered by the neighbors of a cook
+//each neighbor orders a meal in the horizon with a probability given by its orders rate (Poisson process),
+//and chooses the recipe with a probability increasing with its predicted rating (logistic),
+//so the number of portions follows a Poisson binomial distribution
+type DemandForecaster struct {
+	model  core.ModelInterface
+	config DemandConfig
+	//orders per day of each user
+	rates map[string]float64
+}
+
+//NewDemandForecaster fits the model on the orders and computes the orders rate of the users over the period of the orders
+func NewDemandForecaster(m core.ModelInterface, orders dataframe.DataFrame, config DemandConfig) (*DemandForecaster, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	dates, err := ordersDate(orders)
+	if err != nil {
+		return nil, err
+	}
+	if len(dates) == 0 {
+		return nil, errors.New("no order to forecast the demand from")
+	}
+
+	//period of the orders in days, at least a day
+	first, last := dates[0], dates[0]
+	for _, d := range dates {
+		if d.Before(first) {
+			first = d
+		}
+		if d.After(last) {
+			last = d
+		}
+	}
+	days := math.Max(1, last.Sub(first).Hours()/24)
+
+	rates := make(map[string]float64)
+	for _, u := range orders.Col("user_id").Records() {
+		rates[u] += 1 / days
+	}
+
+	m.Fit(ordersDataSet(orders), nil)
+
+	return &DemandForecaster{model: m, config: config, rates: rates}, nil
+}
+
+//probability returns the probability that a user orders a portion of a recipe in the horizon
+func (f *DemandForecaster) probability(userID, recipeID string) (float64, float64) {
+	rating := f.model.Predict(userID, recipeID)
+	ordering := 1 - math.Exp(-f.rates[userID]*f.config.HorizonDays)
+	choosing := 1 / (1 + math.Exp(-f.config.Slope*(rating-f.config.Threshold)))
+
+	return rating, ordering * choosing
+}
+
+//Forecast returns the forecast of the portions of a recipe ordered by neighbors (e.g. the users close to the cook, see Neighborhood)
+//a neighbor orders at most one portion, neighbors without orders never order
+func (f *DemandForecaster) Forecast(recipeID string, neighbors dataframe.DataFrame) Demand {
+	demand := Demand{RecipeID: recipeID}
+	if neighbors.Nrow() == 0 {
+		return demand
+	}
+
+	probabilities := make([]float64, 0, neighbors.Nrow())
+	for _, id := range neighbors.Col("id").Records() {
+		rating, p := f.probability(id, recipeID)
+		demand.PerNeighbor = append(demand.PerNeighbor, NeighborDemand{UserID: id, Rating: rating, Probability: p})
+		probabilities = append(probabilities, p)
+
+		demand.Expected += p
+		demand.StdDev += p * (1 - p)
+	}
+	demand.Neighbors = len(probabilities)
+	demand.StdDev = math.Sqrt(demand.StdDev)
+	sort.SliceStable(demand.PerNeighbor, func(i, j int) bool {
+		return demand.PerNeighbor[i].Probability > demand.PerNeighbor[j].Probability
+	})
+
+	distribution := poissonBinomial(probabilities)
+	demand.Low = quantile(distribution, f.config.Low)
+	demand.High = quantile(distribution, f.config.High)
+
+	return demand
+}
+
+//poissonBinomial returns the distribution of the number of successes of independent trials of probabilities p
+func poissonBinomial(p []float64) []float64 {
+	distribution := make([]float64, len(p)+1)
+	distribution[0] = 1
+	for i, pi := range p {
+		for k := i + 1; k > 0; k-- {
+			distribution[k] = distribution[k]*(1-pi) + distribution[k-1]*pi
+		}
+		distribution[0] *= 1 - pi
+	}
+
+	return distribution
+}
+
+//quantile returns the smallest value whose cumulative probability reaches q
+func quantile(distribution []float64, q float64) int {
+	cumulative := 0.0
+	for k, p := range distribution {
+		cumulative += p
+		if cumulative >= q-1e-12 {
+			return k
+		}
+	}
+
+	return len(distribution) - 1
+}
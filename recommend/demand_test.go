@@ -0,0 +1,91 @@
+package recommend
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/zhenghaoz/gorse/base"
+)
+
+//constantModel predicts the same rating for all users and recipes
+type constantModel struct {
+	*MatrixFactorization
+	rating float64
+}
+
+func (m constantModel) Predict(userID, itemID string) float64 {
+	return m.rating
+}
+
+func TestPoissonBinomial(t *testing.T) {
+	distribution := poissonBinomial([]float64{0.5, 0.5})
+	for k, want := range []float64{0.25, 0.5, 0.25} {
+		if math.Abs(distribution[k]-want) > 1e-12 {
+			t.Errorf("Probability of %d is incorrect, got '%f', want '%f'", k, distribution[k], want)
+		}
+	}
+
+	distribution = poissonBinomial([]float64{0.1, 0.9, 0.3, 1})
+	sum, mean := 0.0, 0.0
+	for k, p := range distribution {
+		sum += p
+		mean += float64(k) * p
+	}
+	if math.Abs(sum-1) > 1e-12 || math.Abs(mean-2.3) > 1e-12 {
+		t.Errorf("Distribution is incorrect, got sum '%f' and mean '%f', want '1' and '2.3'", sum, mean)
+	}
+	if distribution[0] != 0 || quantile(distribution, 0.1) < 1 {
+		t.Error("A certain order should always be counted")
+	}
+}
+
+func TestDemandForecast(t *testing.T) {
+	orders := testOrders()
+	config := DefaultDemandConfig()
+	m := constantModel{NewMatrixFactorization(base.Params{base.NFactors: 2}), config.Threshold}
+
+	forecaster, err := NewDemandForecaster(m, orders, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	neighbors := dataframe.LoadRecords([][]string{{"id"}, {"1"}, {"3"}, {"4"}})
+	demand := forecaster.Forecast("2", neighbors)
+
+	//orders span 121 days, user 1 ordered 3 times, user 3 once and user 4 never
+	//neighbors choose the recipe with probability 1/2 at the threshold rating
+	days := 121.0
+	p1 := 0.5 * (1 - math.Exp(-3/days))
+	p3 := 0.5 * (1 - math.Exp(-1/days))
+	if demand.Neighbors != 3 {
+		t.Errorf("Number of neighbors is incorrect, got '%d', want '%d'", demand.Neighbors, 3)
+	}
+	if math.Abs(demand.Expected-(p1+p3)) > 1e-9 {
+		t.Errorf("Expected portions are incorrect, got '%f', want '%f'", demand.Expected, p1+p3)
+	}
+	if sd := math.Sqrt(p1*(1-p1) + p3*(1-p3)); math.Abs(demand.StdDev-sd) > 1e-9 {
+		t.Errorf("Standard deviation is incorrect, got '%f', want '%f'", demand.StdDev, sd)
+	}
+	if demand.PerNeighbor[0].UserID != "1" || demand.PerNeighbor[2].Probability != 0 {
+		t.Errorf("Neighbors demand is incorrect, got '%v'", demand.PerNeighbor)
+	}
+	if demand.Low != 0 || demand.High != 0 {
+		t.Errorf("Interval is incorrect, got '[%d, %d]', want '[0, 0]'", demand.Low, demand.High)
+	}
+
+	//a longer horizon and a loved recipe increase the demand
+	config.HorizonDays = 365
+	loved := constantModel{NewMatrixFactorization(base.Params{base.NFactors: 2}), 5}
+	forecaster, err = NewDemandForecaster(loved, orders, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if longer := forecaster.Forecast("2", neighbors); longer.Expected <= demand.Expected || longer.High < 1 {
+		t.Errorf("Demand should increase, got '%v'", longer)
+	}
+
+	if empty := forecaster.Forecast("2", dataframe.LoadRecords([][]string{{"id"}})); empty.Expected != 0 || empty.Neighbors != 0 {
+		t.Errorf("Demand without neighbors should be 0, got '%v'", empty)
+	}
+}
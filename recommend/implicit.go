@@ -0,0 +1,106 @@
+package recommend
+
+import (
+	"math"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/go-gota/gota/series"
+	"github.com/zhenghaoz/gorse/core"
+)
+
+//hasColumn returns if the dataframe has a column
+func hasColumn(df dataframe.DataFrame, name string) bool {
+	return columnIndex(df, name) >= 0
+}
+
+//columnIndex returns the index of a column of the dataframe, -1 when it has no such column
+func columnIndex(df dataframe.DataFrame, name string) int {
+	for j, n := range df.Names() {
+		if n == name {
+			return j
+		}
+	}
+	return -1
+}
+
+//isImplicit returns if the orders contain implicit feedback only (no rating)
+func isImplicit(orders dataframe.DataFrame) bool {
+	return !hasColumn(orders, "rating")
+}
+
+//ImplicitOrders replaces the rating of the orders by a confidence
+//every order has a confidence of 1, decaying by half every halfLife before the last order when halfLife is positive
+//repeated orders of a recipe add up their confidence when the orders are split (see ImplicitSplit)
+func ImplicitOrders(orders dataframe.DataFrame, halfLife time.Duration) (dataframe.DataFrame, error) {
+	confidence := make([]float64, orders.Nrow())
+	for i := range confidence {
+		confidence[i] = 1
+	}
+
+	if halfLife > 0 {
+		dates, err := ordersDate(orders)
+		if err != nil {
+			return dataframe.DataFrame{}, err
+		}
+
+		//recency is relative to the last order
+		var last time.Time
+		for _, d := range dates {
+			if d.After(last) {
+				last = d
+			}
+		}
+
+		for i, d := range dates {
+			confidence[i] = math.Pow(2, -float64(last.Sub(d))/float64(halfLife))
+		}
+	}
+
+	implicit := orders.Mutate(series.New(confidence, series.Float, "confidence"))
+	if hasColumn(implicit, "rating") {
+		implicit = implicit.Drop("rating")
+	}
+
+	return implicit, implicit.Err
+}
+
+//aggregateFeedback sums the confidence of the repeated orders of a user for a recipe
+func aggregateFeedback(set core.DataSetInterface) core.DataSetInterface {
+	type pair struct {
+		user, item string
+	}
+
+	var pairs []pair
+	confidence := make(map[pair]float64)
+	for i := 0; i < set.Count(); i++ {
+		user, item, value := set.Get(i)
+		p := pair{user, item}
+		if _, ok := confidence[p]; !ok {
+			pairs = append(pairs, p)
+		}
+		confidence[p] += value
+	}
+
+	users := make([]string, len(pairs))
+	items := make([]string, len(pairs))
+	values := make([]float64, len(pairs))
+	for i, p := range pairs {
+		users[i], items[i], values[i] = p.user, p.item, confidence[p]
+	}
+
+	return core.NewDataSet(users, items, values)
+}
+
+//ImplicitSplit splits orders with split and aggregates the repeated orders of the training and test datasets
+//aggregating after splitting keeps the orders of the test period out of the training confidence
+func ImplicitSplit(split Splitter) Splitter {
+	return func(data core.DataSetInterface, orders dataframe.DataFrame) (core.DataSetInterface, core.DataSetInterface, error) {
+		train, test, err := split(data, orders)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return aggregateFeedback(train), aggregateFeedback(test), nil
+	}
+}
@@ -0,0 +1,62 @@
+package recommend
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+func TestImplicitOrders(t *testing.T) {
+	orders := dataframe.LoadRecords([][]string{
+		{"user_id", "recipe_id", "rating", "date"},
+		{"1", "1", "5", "2020-01-01 00:00:00"},
+		{"1", "1", "4", "2020-01-11 00:00:00"},
+		{"1", "2", "3", "2020-01-21 00:00:00"},
+		{"2", "2", "1", "2020-01-21 00:00:00"},
+	})
+
+	implicit, err := ImplicitOrders(orders, 10*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isImplicit(implicit) {
+		t.Fatal("Implicit orders should not have a rating column")
+	}
+
+	expected := []float64{0.25, 0.5, 1, 1}
+	for i, c := range implicit.Col("confidence").Float() {
+		if math.Abs(c-expected[i]) > 1e-9 {
+			t.Errorf("Confidence of order %d is incorrect, got '%f', want '%f'", i+1, c, expected[i])
+		}
+	}
+
+	//repeated orders add up their confidence
+	data := ordersDataSet(implicit)
+	train, _, err := ImplicitSplit(TemporalSplit(time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC)))(data, implicit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if train.Count() != 1 {
+		t.Fatalf("Repeated orders should be aggregated, got '%d' training orders, want '1'", train.Count())
+	}
+	if _, _, c := train.Get(0); math.Abs(c-0.75) > 1e-9 {
+		t.Errorf("Aggregated confidence is incorrect, got '%f', want '0.75'", c)
+	}
+}
+
+func TestRegistryForFeedback(t *testing.T) {
+	registry := DefaultRegistry()
+
+	for _, c := range registry.ForFeedback(true).Models {
+		if c.Name != "BPR" && c.Name != "WRMF" && c.Name != "MF-Implicit" {
+			t.Errorf("Model %s should not be used with implicit feedback", c.Name)
+		}
+	}
+	for _, c := range registry.ForFeedback(false).Models {
+		if c.Name == "WRMF" {
+			t.Errorf("Model %s should not be used with explicit feedback", c.Name)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package recommend
+
+import (
+	"encoding/gob"
+	"io"
+	"log"
+	"math/rand"
+
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/core"
+	"gonum.org/v1/gonum/mat"
+)
+
+//Implicit is the hyperparameter defining if the in-house models are trained on implicit feedback
+const Implicit base.ParamName = "Implicit"
+
+//Predefined values for hyper-parameter Optimizer of the in-house models
+const (
+	SGD = "sgd"
+	ALS = "als"
+)
+
+//MatrixFactorization is an in-house matrix factorization model
+//explicit feedback: r_ui = mu + b_u + b_i + p_u.q_i trained with SGD or ALS
+//implicit feedback: p_u.q_i fitted on preferences weighted by the confidence 1 + alpha*r_ui trained with ALS (WRMF)
+//Hyper-parameters:
+//  NFactors   - number of latent factors, default 10
+//  NEpochs    - number of epochs, default 20
+//  Lr         - learning rate of SGD, default 0.01
+//  Reg        - regularization strength, default 0.02
+//  InitStdDev - standard deviation of the initial factors, default 0.1
+//  Alpha      - confidence weight of implicit feedback, default 1
+//  Optimizer  - sgd or als, default sgd (implicit feedback is always trained with als)
+//  Implicit   - train on implicit feedback, default false
+//  RandomState - seed of the initialization, default 0
+type MatrixFactorization struct {
+	params base.Params
+
+	//model parameters, exported to be serialized
+	Implicit    bool
+	GlobalMean  float64
+	UserIndex   map[string]int
+	ItemIndex   map[string]int
+	UserBias    []float64
+	ItemBias    []float64
+	UserFactors [][]float64
+	ItemFactors [][]float64
+
+	//hyperparameters
+	nFactors   int
+	nEpochs    int
+	lr         float64
+	reg        float64
+	initStdDev float64
+	alpha      float64
+	optimizer  string
+	seed       int64
+}
+
+//NewMatrixFactorization creates an in-house matrix factorization model
+func NewMatrixFactorization(params base.Params) *MatrixFactorization {
+	mf := new(MatrixFactorization)
+	mf.SetParams(params)
+	return mf
+}
+
+//SetParams sets the hyperparameters of the model
+func (mf *MatrixFactorization) SetParams(params base.Params) {
+	mf.params = params
+	mf.nFactors = params.GetInt(base.NFactors, 10)
+	mf.nEpochs = params.GetInt(base.NEpochs, 20)
+	mf.lr = params.GetFloat64(base.Lr, 0.01)
+	mf.reg = params.GetFloat64(base.Reg, 0.02)
+	mf.initStdDev = params.GetFloat64(base.InitStdDev, 0.1)
+	mf.alpha = params.GetFloat64(base.Alpha, 1)
+	mf.optimizer = params.GetString(base.Optimizer, SGD)
+	mf.seed = params.GetInt64(base.RandomState, 0)
+	mf.Implicit = params.GetBool(Implicit, false)
+}
+
+//GetParams returns the hyperparameters of the model
+func (mf *MatrixFactorization) GetParams() base.Params {
+	return mf.params
+}
+
+//Predict predicts the rating (explicit) or the preference (implicit) of a user for an item
+//unknown users and items fall back to the biases
+func (mf *MatrixFactorization) Predict(userID, itemID string) float64 {
+	u, userOK := mf.UserIndex[userID]
+	i, itemOK := mf.ItemIndex[itemID]
+
+	if mf.Implicit {
+		if !userOK || !itemOK {
+			return 0
+		}
+		return dot(mf.UserFactors[u], mf.ItemFactors[i])
+	}
+
+	prediction := mf.GlobalMean
+	if userOK {
+		prediction += mf.UserBias[u]
+	}
+	if itemOK {
+		prediction += mf.ItemBias[i]
+	}
+	if userOK && itemOK {
+		prediction += dot(mf.UserFactors[u], mf.ItemFactors[i])
+	}
+
+	return prediction
+}
+
+//rating is an observed rating using the model indexes
+type rating struct {
+	user, item int
+	value      float64
+}
+
+//Fit trains the model on a dataset
+func (mf *MatrixFactorization) Fit(trainSet core.DataSetInterface, options *base.RuntimeOptions) {
+	options.Logf("Fit MatrixFactorization with hyper-parameters: n_factors = %v, n_epochs = %v, lr = %v, reg = %v, alpha = %v, optimizer = %v, implicit = %v",
+		mf.nFactors, mf.nEpochs, mf.lr, mf.reg, mf.alpha, mf.optimizer, mf.Implicit)
+
+	//index users and items
+	mf.UserIndex = make(map[string]int, trainSet.UserCount())
+	mf.ItemIndex = make(map[string]int, trainSet.ItemCount())
+	ratings := make([]rating, trainSet.Count())
+	for k := 0; k < trainSet.Count(); k++ {
+		userID, itemID, value := trainSet.Get(k)
+		if _, ok := mf.UserIndex[userID]; !ok {
+			mf.UserIndex[userID] = len(mf.UserIndex)
+		}
+		if _, ok := mf.ItemIndex[itemID]; !ok {
+			mf.ItemIndex[itemID] = len(mf.ItemIndex)
+		}
+		ratings[k] = rating{mf.UserIndex[userID], mf.ItemIndex[itemID], value}
+	}
+
+	//initialize
+	rng := rand.New(rand.NewSource(mf.seed))
+	mf.GlobalMean = 0
+	if !mf.Implicit && len(ratings) > 0 {
+		for _, r := range ratings {
+			mf.GlobalMean += r.value
+		}
+		mf.GlobalMean /= float64(len(ratings))
+	}
+	mf.UserBias = make([]float64, len(mf.UserIndex))
+	mf.ItemBias = make([]float64, len(mf.ItemIndex))
+	mf.UserFactors = randomFactors(rng, len(mf.UserIndex), mf.nFactors, mf.initStdDev)
+	mf.ItemFactors = randomFactors(rng, len(mf.ItemIndex), mf.nFactors, mf.initStdDev)
+
+	if mf.Implicit || mf.optimizer == ALS {
+		mf.fitALS(ratings)
+	} else {
+		mf.fitSGD(rng, ratings)
+	}
+}
+
+//fitSGD trains the explicit model with stochastic gradient descent
+func (mf *MatrixFactorization) fitSGD(rng *rand.Rand, ratings []rating) {
+	for epoch := 0; epoch < mf.nEpochs; epoch++ {
+		for _, k := range rng.Perm(len(ratings)) {
+			r := ratings[k]
+			p, q := mf.UserFactors[r.user], mf.ItemFactors[r.item]
+			e := r.value - (mf.GlobalMean + mf.UserBias[r.user] + mf.ItemBias[r.item] + dot(p, q))
+
+			mf.UserBias[r.user] += mf.lr * (e - mf.reg*mf.UserBias[r.user])
+			mf.ItemBias[r.item] += mf.lr * (e - mf.reg*mf.ItemBias[r.item])
+			for f := range p {
+				pf, qf := p[f], q[f]
+				p[f] += mf.lr * (e*qf - mf.reg*pf)
+				q[f] += mf.lr * (e*pf - mf.reg*qf)
+			}
+		}
+	}
+}
+
+//fitALS trains the model with alternating least squares
+func (mf *MatrixFactorization) fitALS(ratings []rating) {
+	byUser := make([][]rating, len(mf.UserIndex))
+	byItem := make([][]rating, len(mf.ItemIndex))
+	for _, r := range ratings {
+		byUser[r.user] = append(byUser[r.user], r)
+		byItem[r.item] = append(byItem[r.item], r)
+	}
+
+	for epoch := 0; epoch < mf.nEpochs; epoch++ {
+		//users with items fixed
+		gram := mf.gram(mf.ItemFactors)
+		for u := range byUser {
+			items := make([]int, len(byUser[u]))
+			values := make([]float64, len(byUser[u]))
+			for k, r := range byUser[u] {
+				items[k], values[k] = r.item, r.value
+			}
+			mf.UserFactors[u], mf.UserBias[u] = mf.solve(gram, mf.ItemFactors, mf.ItemBias, items, values)
+		}
+
+		//items with users fixed
+		gram = mf.gram(mf.UserFactors)
+		for i := range byItem {
+			users := make([]int, len(byItem[i]))
+			values := make([]float64, len(byItem[i]))
+			for k, r := range byItem[i] {
+				users[k], values[k] = r.user, r.value
+			}
+			mf.ItemFactors[i], mf.ItemBias[i] = mf.solve(gram, mf.UserFactors, mf.UserBias, users, values)
+		}
+	}
+}
+
+//gram returns the gram matrix of the factors used by the implicit ALS, nil for explicit feedback
+func (mf *MatrixFactorization) gram(factors [][]float64) *mat.SymDense {
+	if !mf.Implicit {
+		return nil
+	}
+
+	gram := mat.NewSymDense(mf.nFactors, nil)
+	for _, y := range factors {
+		gram.SymRankOne(gram, 1, mat.NewVecDense(mf.nFactors, y))
+	}
+	return gram
+}
+
+//solve computes the factors (and bias for explicit feedback) of a row given the fixed factors of its observed columns
+//explicit: min sum (r - mu - b_col - b - x.y)^2 + reg (|x|^2 + b^2)
+//implicit: min sum_all c (p - x.y)^2 + reg |x|^2 with p = 1 and c = 1 + alpha*r for observed columns, p = 0 and c = 1 otherwise
+func (mf *MatrixFactorization) solve(gram *mat.SymDense, fixed [][]float64, fixedBias []float64, columns []int, values []float64) ([]float64, float64) {
+	//explicit feedback solves the bias along the factors
+	dim := mf.nFactors
+	if !mf.Implicit {
+		dim++
+	}
+
+	a := mat.NewSymDense(dim, nil)
+	if gram != nil {
+		a.CopySym(gram)
+	}
+	b := mat.NewVecDense(dim, nil)
+	y := mat.NewVecDense(dim, nil)
+	for k, col := range columns {
+		copy(y.RawVector().Data, fixed[col])
+		if mf.Implicit {
+			c := 1 + mf.alpha*values[k]
+			//Y^T (C - I) Y and Y^T C p
+			a.SymRankOne(a, c-1, y)
+			b.AddScaledVec(b, c, y)
+		} else {
+			y.SetVec(mf.nFactors, 1)
+			a.SymRankOne(a, 1, y)
+			b.AddScaledVec(b, values[k]-mf.GlobalMean-fixedBias[col], y)
+		}
+	}
+	for d := 0; d < dim; d++ {
+		a.SetSym(d, d, a.At(d, d)+mf.reg)
+	}
+
+	var chol mat.Cholesky
+	if ok := chol.Factorize(a); !ok {
+		log.Println("MatrixFactorization: singular system, keeping zero factors")
+		return make([]float64, mf.nFactors), 0
+	}
+	x := mat.NewVecDense(dim, nil)
+	if err := chol.SolveVecTo(x, b); err != nil {
+		log.Printf("MatrixFactorization: %v\n", err)
+	}
+
+	factors := make([]float64, mf.nFactors)
+	copy(factors, x.RawVector().Data)
+	if mf.Implicit {
+		return factors, 0
+	}
+	return factors, x.AtVec(mf.nFactors)
+}
+
+//FoldIn computes the factors of a user from its ratings with the item factors fixed
+//a new user is added to the model, unknown items are ignored
+func (mf *MatrixFactorization) FoldIn(userID string, itemIDs []string, ratings []float64) {
+	var items []int
+	var values []float64
+	for k, itemID := range itemIDs {
+		if i, ok := mf.ItemIndex[itemID]; ok {
+			items = append(items, i)
+			values = append(values, ratings[k])
+		}
+	}
+
+	factors, bias := mf.solve(mf.gram(mf.ItemFactors), mf.ItemFactors, mf.ItemBias, items, values)
+
+	u, ok := mf.UserIndex[userID]
+	if !ok {
+		u = len(mf.UserFactors)
+		mf.UserIndex[userID] = u
+		mf.UserFactors = append(mf.UserFactors, nil)
+		mf.UserBias = append(mf.UserBias, 0)
+	}
+	mf.UserFactors[u], mf.UserBias[u] = factors, bias
+}
+
+//mfState is the serialized state of a matrix factorization
+type mfState struct {
+	Params map[string]interface{}
+	Model  MatrixFactorization
+}
+
+//Save serializes the fitted model with its hyperparameters
+func (mf *MatrixFactorization) Save(w io.Writer) error {
+	state := mfState{Params: make(map[string]interface{}, len(mf.params)), Model: *mf}
+	for name, value := range mf.params {
+		state.Params[string(name)] = value
+	}
+	return gob.NewEncoder(w).Encode(state)
+}
+
+//LoadMatrixFactorization loads a model serialized with Save
+func LoadMatrixFactorization(r io.Reader) (*MatrixFactorization, error) {
+	var state mfState
+	if err := gob.NewDecoder(r).Decode(&state); err != nil {
+		return nil, err
+	}
+
+	params, err := ParseParams(state.Params)
+	if err != nil {
+		return nil, err
+	}
+	mf := &state.Model
+	mf.SetParams(params)
+
+	return mf, nil
+}
+
+//randomFactors initializes factors with a normal distribution
+func randomFactors(rng *rand.Rand, n, nFactors int, stdDev float64) [][]float64 {
+	factors := make([][]float64, n)
+	for i := range factors {
+		factors[i] = make([]float64, nFactors)
+		for f := range factors[i] {
+			factors[i][f] = rng.NormFloat64() * stdDev
+		}
+	}
+	return factors
+}
+
+//dot computes the dot product of two vectors
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
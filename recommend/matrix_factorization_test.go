@@ -0,0 +1,105 @@
+package recommend
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/core"
+)
+
+//testRankOneDataSet generates ratings of a rank one matrix with two groups of users and items
+func testRankOneDataSet() *core.DataSet {
+	var users, items []string
+	var ratings []float64
+	for u := 0; u < 20; u++ {
+		for i := 0; i < 10; i++ {
+			if (u+i)%4 == 0 {
+				continue
+			}
+			rating := 1.0
+			if (u < 10) == (i < 5) {
+				rating = 5
+			}
+			users = append(users, fmt.Sprint(u))
+			items = append(items, fmt.Sprint(i))
+			ratings = append(ratings, rating)
+		}
+	}
+	return core.NewDataSet(users, items, ratings)
+}
+
+func TestMatrixFactorizationExplicit(t *testing.T) {
+	data := testRankOneDataSet()
+
+	for _, optimizer := range []string{SGD, ALS} {
+		mf := NewMatrixFactorization(base.Params{
+			base.NFactors:  2,
+			base.NEpochs:   100,
+			base.Lr:        0.05,
+			base.Reg:       0.01,
+			base.Optimizer: optimizer,
+		})
+		mf.Fit(data, nil)
+
+		if rmse := core.EvaluateRating(mf, data, core.RMSE)[0]; rmse > 0.5 {
+			t.Errorf("Training RMSE with %s is incorrect, got '%f', want less than '0.5'", optimizer, rmse)
+		}
+
+		//missing rating of the same group
+		if mf.Predict("0", "4") < mf.Predict("0", "8") {
+			t.Errorf("Prediction with %s is incorrect, user should prefer items of its group", optimizer)
+		}
+	}
+}
+
+func TestMatrixFactorizationImplicit(t *testing.T) {
+	//users only order the items of their group
+	var users, items []string
+	var counts []float64
+	for u := 0; u < 20; u++ {
+		for i := 0; i < 10; i++ {
+			if (u < 10) == (i < 5) && (u+i)%4 != 0 {
+				users = append(users, fmt.Sprint(u))
+				items = append(items, fmt.Sprint(i))
+				counts = append(counts, float64(1+u%3))
+			}
+		}
+	}
+
+	mf := NewMatrixFactorization(base.Params{base.NFactors: 2, base.NEpochs: 10, base.Reg: 0.1, base.Alpha: 10, Implicit: true})
+	mf.Fit(core.NewDataSet(users, items, counts), nil)
+
+	if mf.Predict("0", "4") <= mf.Predict("0", "8") {
+		t.Errorf("Preference is incorrect, got '%f' for an item of the group and '%f' otherwise", mf.Predict("0", "4"), mf.Predict("0", "8"))
+	}
+}
+
+func TestMatrixFactorizationFoldIn(t *testing.T) {
+	mf := NewMatrixFactorization(base.Params{base.NFactors: 2, base.NEpochs: 50, base.Reg: 0.01, base.Optimizer: ALS})
+	mf.Fit(testRankOneDataSet(), nil)
+
+	//new user of the first group
+	mf.FoldIn("new", []string{"0", "1", "7"}, []float64{5, 5, 1})
+	if mf.Predict("new", "3") <= mf.Predict("new", "8") {
+		t.Errorf("Fold in is incorrect, got '%f' for an item of the group and '%f' otherwise", mf.Predict("new", "3"), mf.Predict("new", "8"))
+	}
+
+	//serialization keeps predictions and hyperparameters
+	var buffer bytes.Buffer
+	if err := mf.Save(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadMatrixFactorization(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(loaded.Predict("new", "3")-mf.Predict("new", "3")) > 1e-12 {
+		t.Errorf("Loaded prediction is incorrect, got '%f', want '%f'", loaded.Predict("new", "3"), mf.Predict("new", "3"))
+	}
+	if loaded.GetParams().GetString(base.Optimizer, "") != ALS {
+		t.Errorf("Loaded hyperparameters are incorrect, got '%v'", loaded.GetParams())
+	}
+}
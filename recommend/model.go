@@ -0,0 +1,17 @@
+package recommend
+
+import (
+	"io"
+
+	"github.com/zhenghaoz/gorse/core"
+)
+
+//Model is the interface of the in-house recommendation models
+//it extends the gorse model interface so in-house models are trained, tuned and evaluated like gorse models
+type Model interface {
+	core.ModelInterface
+	//FoldIn computes the factors of a new or existing user from its ratings without retraining the model
+	FoldIn(userID string, itemIDs []string, ratings []float64)
+	//Save serializes the fitted model
+	Save(w io.Writer) error
+}
@@ -0,0 +1,117 @@
+package recommend
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+//Neighborhood defines which users are neighbors of a user
+type Neighborhood interface {
+	//Neighbors returns the neighboring users of a user, from the closest to the farthest
+	Neighbors(userID int) dataframe.DataFrame
+}
+
+//RadiusNeighborhood contains the users at most Km away in a straight line
+type RadiusNeighborhood struct {
+	index *UsersIndex
+	Km    float64
+}
+
+//NewRadiusNeighborhood creates the neighborhood of the users at most km away in a straight line
+func NewRadiusNeighborhood(users dataframe.DataFrame, km float64) (*RadiusNeighborhood, error) {
+	index, err := NewUsersIndex(users)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RadiusNeighborhood{index: index, Km: km}, nil
+}
+
+//Neighbors returns the users at most Km away from the user
+func (n *RadiusNeighborhood) Neighbors(userID int) dataframe.DataFrame {
+	return n.index.CloseByXKm(userID, n.Km)
+}
+
+//TravelTimeNeighborhood contains the users reachable in at most Minutes on a road graph
+type TravelTimeNeighborhood struct {
+	users   dataframe.DataFrame
+	graph   *util.RoadGraph
+	Minutes float64
+
+	//row of each user in the users dataframe
+	rows map[int]int
+	//closest node of the graph of each user and the minutes to reach it
+	nodes  map[int]int
+	access map[int]float64
+	//users by closest node
+	nodeUsers map[int][]int
+}
+
+//NewTravelTimeNeighborhood creates the neighborhood of the users reachable in at most minutes with the travel mode of the graph
+func NewTravelTimeNeighborhood(users dataframe.DataFrame, graph *util.RoadGraph, minutes float64) (*TravelTimeNeighborhood, error) {
+	if !hasColumn(users, "id") || !hasColumn(users, "latitude") || !hasColumn(users, "longitude") {
+		return nil, errors.New("users need id, latitude and longitude columns")
+	}
+
+	ids, err := users.Col("id").Int()
+	if err != nil {
+		return nil, err
+	}
+	latitudes := users.Col("latitude").Float()
+	longitudes := users.Col("longitude").Float()
+
+	n := &TravelTimeNeighborhood{
+		users:     users,
+		graph:     graph,
+		Minutes:   minutes,
+		rows:      make(map[int]int, len(ids)),
+		nodes:     make(map[int]int, len(ids)),
+		access:    make(map[int]float64, len(ids)),
+		nodeUsers: make(map[int][]int),
+	}
+	for i, id := range ids {
+		node, access := graph.Snap(latitudes[i], longitudes[i])
+		n.rows[id] = i
+		n.nodes[id] = node
+		n.access[id] = access
+		n.nodeUsers[node] = append(n.nodeUsers[node], id)
+	}
+
+	return n, nil
+}
+
+//Neighbors returns the users reachable from the user in at most Minutes, from the closest to the farthest
+//the travel time includes the straight line from the users to their closest road
+func (n *TravelTimeNeighborhood) Neighbors(userID int) dataframe.DataFrame {
+	node, ok := n.nodes[userID]
+	if !ok {
+		return dataframe.DataFrame{}
+	}
+
+	//isochrone from the user
+	var neighbors []util.Neighbor
+	for reached, minutes := range n.graph.Isochrone(node, n.Minutes-n.access[userID]) {
+		for _, id := range n.nodeUsers[reached] {
+			t := n.access[userID] + minutes + n.access[id]
+			if id != userID && t <= n.Minutes {
+				neighbors = append(neighbors, util.Neighbor{ID: id, Distance: t})
+			}
+		}
+	}
+	sort.Slice(neighbors, func(i, j int) bool {
+		if neighbors[i].Distance == neighbors[j].Distance {
+			return neighbors[i].ID < neighbors[j].ID
+		}
+		return neighbors[i].Distance < neighbors[j].Distance
+	})
+
+	rows := []int{}
+	for _, neighbor := range neighbors {
+		rows = append(rows, n.rows[neighbor.ID])
+	}
+
+	return n.users.Subset(rows)
+}
@@ -0,0 +1,60 @@
+package recommend
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+func TestTravelTimeNeighborhood(t *testing.T) {
+	//a street along a canal, users 1 and 3 are close in a straight line but far by road
+	graph, err := util.ReadOSM(strings.NewReader(`<osm>
+		<node id="1" lat="52.000" lon="6.00"/>
+		<node id="2" lat="52.000" lon="6.01"/>
+		<node id="3" lat="52.005" lon="6.01"/>
+		<node id="4" lat="52.005" lon="6.00"/>
+		<way id="10">
+			<nd ref="1"/><nd ref="2"/><nd ref="3"/><nd ref="4"/>
+			<tag k="highway" v="residential"/>
+		</way>
+	</osm>`), util.Walking)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := dataframe.LoadRecords([][]string{
+		{"id", "latitude", "longitude"},
+		{"1", "52.000", "6.00"},
+		{"2", "52.000", "6.01"},
+		{"3", "52.005", "6.00"},
+	})
+
+	radius, err := NewRadiusNeighborhood(users, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := radius.Neighbors(1).Col("id").Int(); !reflect.DeepEqual(ids, []int{3, 2}) {
+		t.Errorf("Neighbors in a radius are incorrect, got '%v', want '[3 2]'", ids)
+	}
+
+	//about 8 minutes to user 2 and 23 minutes to user 3
+	travel, err := NewTravelTimeNeighborhood(users, graph, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := travel.Neighbors(1).Col("id").Int(); !reflect.DeepEqual(ids, []int{2}) {
+		t.Errorf("Neighbors by travel time are incorrect, got '%v', want '[2]'", ids)
+	}
+
+	travel.Minutes = 30
+	if ids, _ := travel.Neighbors(1).Col("id").Int(); !reflect.DeepEqual(ids, []int{2, 3}) {
+		t.Errorf("Neighbors by travel time are incorrect, got '%v', want '[2 3]'", ids)
+	}
+
+	if travel.Neighbors(42).Nrow() != 0 {
+		t.Error("Unknown user should not have neighbors")
+	}
+}
@@ -0,0 +1,95 @@
+package recommend
+
+import (
+	"errors"
+	"math"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+//usersCellKm is the size of the spatial index cells, close to the usual neighbors radius
+const usersCellKm = 2
+
+//UsersIndex is a spatial index of the users locations, built once to answer many neighbors queries
+type UsersIndex struct {
+	users dataframe.DataFrame
+	index *util.GeoIndex
+	//row of each user in the users dataframe and its location
+	rows      map[int]int
+	locations []util.Geolocation
+}
+
+//NewUsersIndex creates the spatial index of users
+func NewUsersIndex(users dataframe.DataFrame) (*UsersIndex, error) {
+	if !hasColumn(users, "id") || !hasColumn(users, "latitude") || !hasColumn(users, "longitude") {
+		return nil, errors.New("users need id, latitude and longitude columns")
+	}
+
+	ids, err := users.Col("id").Int()
+	if err != nil {
+		return nil, err
+	}
+	latitudes := users.Col("latitude").Float()
+	longitudes := users.Col("longitude").Float()
+
+	u := &UsersIndex{
+		users: users,
+		index: util.NewGeoIndex(usersCellKm),
+		rows:  make(map[int]int, len(ids)),
+	}
+	for i, id := range ids {
+		u.locations = append(u.locations, util.Geolocation{Latitude: latitudes[i], Longitude: longitudes[i]})
+		//users without location have no neighbors and are nobody's neighbor
+		if math.IsNaN(latitudes[i]) || math.IsNaN(longitudes[i]) {
+			continue
+		}
+		u.rows[id] = i
+		u.index.Add(id, latitudes[i], longitudes[i])
+	}
+
+	return u, nil
+}
+
+//location returns the location of a user, false when the user is unknown or has no location
+func (u *UsersIndex) location(userID int) (util.Geolocation, bool) {
+	row, ok := u.rows[userID]
+	if !ok {
+		return util.Geolocation{}, false
+	}
+
+	return u.locations[row], true
+}
+
+//subset returns the users rows of at most k neighbors, excluding the user itself
+func (u *UsersIndex) subset(userID int, neighbors []util.Neighbor, k int) dataframe.DataFrame {
+	rows := []int{}
+	for _, n := range neighbors {
+		if n.ID != userID && (k < 0 || len(rows) < k) {
+			rows = append(rows, u.rows[n.ID])
+		}
+	}
+
+	return u.users.Subset(rows)
+}
+
+//CloseByXKm returns the users at most km away from the user, from the closest to the farthest
+func (u *UsersIndex) CloseByXKm(userID int, km float64) dataframe.DataFrame {
+	l, ok := u.location(userID)
+	if !ok {
+		return dataframe.DataFrame{}
+	}
+
+	return u.subset(userID, u.index.Within(l.Latitude, l.Longitude, km), -1)
+}
+
+//Nearest returns the k closest users to the user, from the closest to the farthest
+func (u *UsersIndex) Nearest(userID, k int) dataframe.DataFrame {
+	l, ok := u.location(userID)
+	if !ok {
+		return dataframe.DataFrame{}
+	}
+
+	//the user itself is one of the closest locations
+	return u.subset(userID, u.index.Nearest(l.Latitude, l.Longitude, k+1), k)
+}
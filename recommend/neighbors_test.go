@@ -0,0 +1,42 @@
+package recommend
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+func TestUsersCloseByXKm(t *testing.T) {
+	//user 4 is in the corner of the bounding coordinates of user 1, more than 5 km away
+	bounds := util.BoundingCoordinates(52.21, 6.88, 5)
+	users := dataframe.LoadRecords([][]string{
+		{"id", "latitude", "longitude"},
+		{"1", "52.21", "6.88"},
+		{"2", "52.23", "6.89"},
+		{"3", "52.211", "6.881"},
+		{"4", strconv.FormatFloat(bounds[1].Latitude, 'f', -1, 64), strconv.FormatFloat(bounds[1].Longitude, 'f', -1, 64)},
+		{"5", "53.21", "6.88"},
+	})
+
+	neighbors := UsersCloseByXKm(1, 5, users)
+	if ids, _ := neighbors.Col("id").Int(); !reflect.DeepEqual(ids, []int{3, 2}) {
+		t.Errorf("Neighbors are incorrect, got '%v', want '[3 2]'", ids)
+	}
+
+	neighbors = UsersCloseByXKm(1, 0.01, users)
+	if neighbors.Nrow() != 0 {
+		t.Errorf("Neighbors are incorrect, got '%d', want '0'", neighbors.Nrow())
+	}
+
+	index, err := NewUsersIndex(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nearest := index.Nearest(1, 3)
+	if ids, _ := nearest.Col("id").Int(); !reflect.DeepEqual(ids, []int{3, 2, 4}) {
+		t.Errorf("Nearest users are incorrect, got '%v', want '[3 2 4]'", ids)
+	}
+}
@@ -0,0 +1,235 @@
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+//OnlineRecommender serves recommendations from a fitted model and updates them incrementally when an order arrives
+//user factors are folded in the model, content filtering tags weight and co-ordering neighbors are updated in place
+type OnlineRecommender struct {
+	mu       sync.RWMutex
+	model    Model
+	implicit bool
+
+	//recipes
+	recipes    []string
+	known      map[string]bool
+	recipeTags map[string][]string
+
+	//orders history, latest rating or summed confidence per recipe
+	ratings map[string]map[string]float64
+
+	//content filtering profiles (see userTagsWeight)
+	ratingSum   map[string]float64
+	ratingCount map[string]int
+	tagSum      map[string]map[string]float64
+	tagCount    map[string]map[string]int
+
+	//users who ordered each recipe and number of recipes ordered in common by two users
+	recipeUsers map[string]map[string]bool
+	coOrders    map[string]map[string]int
+}
+
+//NewOnlineRecommender creates an online recommender from a fitted model and the orders it was fitted on
+func NewOnlineRecommender(m Model, orders, recipes dataframe.DataFrame) (*OnlineRecommender, error) {
+	if !hasColumn(orders, "user_id") || !hasColumn(orders, "recipe_id") || !hasColumn(recipes, "id") {
+		return nil, errors.New("orders need user_id and recipe_id columns and recipes an id column")
+	}
+
+	o := &OnlineRecommender{
+		model:       m,
+		implicit:    m.GetParams().GetBool(Implicit, false),
+		known:       make(map[string]bool),
+		recipeTags:  make(map[string][]string),
+		ratings:     make(map[string]map[string]float64),
+		ratingSum:   make(map[string]float64),
+		ratingCount: make(map[string]int),
+		tagSum:      make(map[string]map[string]float64),
+		tagCount:    make(map[string]map[string]int),
+		recipeUsers: make(map[string]map[string]bool),
+		coOrders:    make(map[string]map[string]int),
+	}
+
+	//tags of each recipe
+	names := recipes.Names()
+	idColumn := columnIndex(recipes, "id")
+	for _, record := range recipes.Records()[1:] {
+		id := record[idColumn]
+		o.recipes = append(o.recipes, id)
+		o.known[id] = true
+		for j, n := range names {
+			if strings.Contains(n, "tag_") && record[j] == "1" {
+				o.recipeTags[id] = append(o.recipeTags[id], n)
+			}
+		}
+	}
+
+	//replay orders history
+	data := ordersDataSet(orders)
+	for i := 0; i < data.Count(); i++ {
+		user, recipe, value := data.Get(i)
+		o.record(user, recipe, value)
+	}
+
+	return o, nil
+}
+
+//record updates the history and the profiles with an order
+func (o *OnlineRecommender) record(user, recipe string, value float64) {
+	//history
+	if o.ratings[user] == nil {
+		o.ratings[user] = make(map[string]float64)
+	}
+	_, reordered := o.ratings[user][recipe]
+	if o.implicit {
+		o.ratings[user][recipe] += value
+	} else {
+		o.ratings[user][recipe] = value
+	}
+
+	//tags weight
+	o.ratingSum[user] += value
+	o.ratingCount[user]++
+	if o.tagSum[user] == nil {
+		o.tagSum[user] = make(map[string]float64)
+		o.tagCount[user] = make(map[string]int)
+	}
+	for _, tag := range o.recipeTags[recipe] {
+		o.tagSum[user][tag] += value
+		o.tagCount[user][tag]++
+	}
+
+	//co-ordering neighbors
+	if reordered {
+		return
+	}
+	if o.recipeUsers[recipe] == nil {
+		o.recipeUsers[recipe] = make(map[string]bool)
+	}
+	if o.coOrders[user] == nil {
+		o.coOrders[user] = make(map[string]int)
+	}
+	for other := range o.recipeUsers[recipe] {
+		if o.coOrders[other] == nil {
+			o.coOrders[other] = make(map[string]int)
+		}
+		o.coOrders[user][other]++
+		o.coOrders[other][user]++
+	}
+	o.recipeUsers[recipe][user] = true
+}
+
+//AddOrder records an order of a user and updates its recommendations immediately
+//rating is the order rating, or its confidence for a model trained on implicit feedback
+func (o *OnlineRecommender) AddOrder(userID, recipeID int, rating float64) error {
+	user, recipe := strconv.Itoa(userID), strconv.Itoa(recipeID)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.known[recipe] {
+		return fmt.Errorf("recipe %d does not exist", recipeID)
+	}
+
+	o.record(user, recipe, rating)
+
+	//fold in the user with its full history
+	var recipes []string
+	var ratings []float64
+	for r, v := range o.ratings[user] {
+		recipes = append(recipes, r)
+		ratings = append(ratings, v)
+	}
+	o.model.FoldIn(user, recipes, ratings)
+
+	return nil
+}
+
+//Recommend returns the nbRecipes recipes with the best prediction that the user has not ordered yet
+//recipes not allowed by filter are never recommended, nil allows all recipes
+//reranker adapts the ranking to the context of the request (see Context), nil to keep the ranking
+func (o *OnlineRecommender) Recommend(userID, nbRecipes int, filter RecipeFilter, reranker Reranker) []string {
+	user := strconv.Itoa(userID)
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var candidates []kv
+	for _, r := range o.recipes {
+		if _, ordered := o.ratings[user][r]; ordered || !filter.allows(r) {
+			continue
+		}
+		candidates = append(candidates, kv{r, o.model.Predict(user, r)})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Value > candidates[j].Value
+	})
+	rerank(candidates, reranker)
+
+	if len(candidates) > nbRecipes {
+		candidates = candidates[:nbRecipes]
+	}
+	recommendItems := make([]string, len(candidates))
+	for i, c := range candidates {
+		recommendItems[i] = c.Key
+	}
+
+	return recommendItems
+}
+
+//TagsWeight returns the current tags weight of a user, as computed by the content filtering from its orders
+func (o *OnlineRecommender) TagsWeight(userID int) map[string]float64 {
+	user := strconv.Itoa(userID)
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	weight := make(map[string]float64)
+	if o.ratingCount[user] == 0 {
+		return weight
+	}
+
+	//mean of the normalized ratings of the orders having the tag
+	mean := o.ratingSum[user] / float64(o.ratingCount[user])
+	for tag, sum := range o.tagSum[user] {
+		weight[tag] = sum/float64(o.tagCount[user][tag]) - mean
+	}
+
+	return weight
+}
+
+//Neighbors returns the k users with the most recipes ordered in common with the user
+func (o *OnlineRecommender) Neighbors(userID, k int) []string {
+	user := strconv.Itoa(userID)
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var neighbors []kv
+	for other, count := range o.coOrders[user] {
+		neighbors = append(neighbors, kv{other, float64(count)})
+	}
+	sort.Slice(neighbors, func(i, j int) bool {
+		if neighbors[i].Value == neighbors[j].Value {
+			return neighbors[i].Key < neighbors[j].Key
+		}
+		return neighbors[i].Value > neighbors[j].Value
+	})
+
+	if len(neighbors) > k {
+		neighbors = neighbors[:k]
+	}
+	ids := make([]string, len(neighbors))
+	for i, n := range neighbors {
+		ids[i] = n.Key
+	}
+
+	return ids
+}
@@ -0,0 +1,102 @@
+package recommend
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/go-gota/gota/series"
+	"github.com/zhenghaoz/gorse/base"
+)
+
+func testRecipes() dataframe.DataFrame {
+	return dataframe.LoadRecords([][]string{
+		{"id", "title", "totalTime", "tag_vegetarisch", "tag_snel", "ingredient_kip", "ingredient_pasta"},
+		{"1", "Pasta pesto", "20", "1", "1", "0", "1"},
+		{"2", "Kip curry", "45", "0", "0", "1", "0"},
+		{"3", "Pasta kip", "30", "0", "1", "1", "1"},
+		{"4", "Salade", "10", "1", "1", "0", "0"},
+	})
+}
+
+func TestOnlineRecommender(t *testing.T) {
+	orders := testOrders()
+	recipes := testRecipes()
+
+	mf := NewMatrixFactorization(base.Params{base.NFactors: 2, base.NEpochs: 10, base.Optimizer: ALS})
+	mf.Fit(ordersDataSet(orders), nil)
+
+	online, err := NewOnlineRecommender(mf, orders, recipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//new user gets recommendations as soon as it orders
+	if err := online.AddOrder(4, 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := online.AddOrder(4, 42, 5); err == nil {
+		t.Error("Expected an error for an unknown recipe")
+	}
+
+	recommendItems := online.Recommend(4, 10, nil, nil)
+	if len(recommendItems) != 3 {
+		t.Errorf("Recommendations are incorrect, got '%v', want the 3 recipes not ordered", recommendItems)
+	}
+	for _, r := range recommendItems {
+		if r == "1" {
+			t.Error("Ordered recipe should not be recommended")
+		}
+	}
+	if _, ok := mf.UserIndex["4"]; !ok {
+		t.Error("New user should be folded in the model")
+	}
+
+	//tags weight are the same as the content filtering ones
+	if err := online.AddOrder(1, 4, 2); err != nil {
+		t.Fatal(err)
+	}
+	updated := orders.RBind(dataframe.LoadRecords([][]string{
+		{"user_id", "recipe_id", "rating", "date"},
+		{"4", "1", "5", "2020-06-01 12:00:00"},
+		{"1", "4", "2", "2020-06-01 12:00:00"},
+	}))
+	//ratings as float so that the normalized ratings are not truncated
+	updated = updated.Mutate(series.New(updated.Col("rating").Float(), series.Float, "rating"))
+	for _, userID := range []int{1, 4} {
+		expected := userTagsWeight(userProfileOrder(userID, updated, recipes))
+		weight := online.TagsWeight(userID)
+		if len(weight) != len(expected) {
+			t.Errorf("Tags weight of user %d is incorrect, got '%v', want '%v'", userID, weight, expected)
+		}
+		for tag, w := range expected {
+			if math.Abs(weight[tag]-w) > 1e-9 {
+				t.Errorf("Weight of %s for user %d is incorrect, got '%f', want '%f'", tag, userID, weight[tag], w)
+			}
+		}
+	}
+
+	//users 1 and 2 ordered recipes 1 and 3 in common
+	neighbors := online.Neighbors(2, 1)
+	if len(neighbors) != 1 || neighbors[0] != "1" {
+		t.Errorf("Neighbors are incorrect, got '%v', want '[1]'", neighbors)
+	}
+}
+
+func TestOnlineRecommenderColumnOrder(t *testing.T) {
+	//id is not the first column
+	recipes := testRecipes().Select([]string{"title", "tag_snel", "id"})
+	mf := NewMatrixFactorization(base.Params{base.NFactors: 2})
+	mf.Fit(ordersDataSet(testOrders()), nil)
+
+	online, err := NewOnlineRecommender(mf, testOrders(), recipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !online.known["2"] || online.known["Kip curry"] {
+		t.Error("Recipes should be identified by their id column")
+	}
+	if len(online.recipeTags["1"]) != 1 || len(online.recipeTags["2"]) != 0 {
+		t.Errorf("Recipes tags are incorrect, got '%v'", online.recipeTags)
+	}
+}
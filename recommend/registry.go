@@ -0,0 +1,371 @@
+package recommend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/core"
+	"github.com/zhenghaoz/gorse/model"
+)
+
+//ModelConfig contains the configuration of a collaborative filtering model
+type ModelConfig struct {
+	//Name identifies the model in the registry and in the results
+	Name string `json:"name"`
+	//Model is the kind of model (see modelConstructors)
+	Model string `json:"model"`
+	//Enabled defines if the model is used by default, models are enabled when omitted
+	Enabled *bool `json:"enabled,omitempty"`
+	//Params contains the hyperparameters of the model using gorse parameters names
+	Params map[string]interface{} `json:"params,omitempty"`
+	//Feedback is the feedback the model is suited for: explicit (default), implicit or both
+	Feedback string `json:"feedback,omitempty"`
+}
+
+//IsEnabled returns if the model is used by default
+func (c ModelConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+//Supports returns if the model is suited for implicit or explicit feedback
+func (c ModelConfig) Supports(implicit bool) bool {
+	switch c.Feedback {
+	case "both":
+		return true
+	case "implicit":
+		return implicit
+	default:
+		return !implicit
+	}
+}
+
+//rankingModels are the kinds of models whose predictions score the items without being ratings
+var rankingModels = map[string]bool{"itempop": true, "bpr": true, "wrmf": true, "knnimplicit": true}
+
+//PredictsRatings returns if the predictions of the model are ratings
+//models for implicit feedback and ranking models only score the items
+func (c ModelConfig) PredictsRatings() bool {
+	if !c.Supports(false) || rankingModels[strings.ToLower(c.Model)] {
+		return false
+	}
+	implicit, _ := c.Params[string(Implicit)].(bool)
+	return !implicit
+}
+
+//Registry contains the configured collaborative filtering models
+type Registry struct {
+	Models []ModelConfig `json:"models"`
+}
+
+//NamedModel is a model built from the registry
+type NamedModel struct {
+	Name  string
+	Model core.ModelInterface
+}
+
+//modelConstructors contains the supported gorse models
+var modelConstructors = map[string]func(params base.Params) core.ModelInterface{
+	"baseline":     func(params base.Params) core.ModelInterface { return model.NewBaseLine(params) },
+	"itempop":      func(params base.Params) core.ModelInterface { return model.NewItemPop(params) },
+	"slopeone":     func(params base.Params) core.ModelInterface { return model.NewSlopOne(params) },
+	"coclustering": func(params base.Params) core.ModelInterface { return model.NewCoClustering(params) },
+	"svd":          func(params base.Params) core.ModelInterface { return model.NewSVD(params) },
+	"svdpp":        func(params base.Params) core.ModelInterface { return model.NewSVDpp(params) },
+	"nmf":          func(params base.Params) core.ModelInterface { return model.NewNMF(params) },
+	"bpr":          func(params base.Params) core.ModelInterface { return model.NewBPR(params) },
+	"wrmf":         func(params base.Params) core.ModelInterface { return model.NewWRMF(params) },
+	"knn":          func(params base.Params) core.ModelInterface { return model.NewKNN(params) },
+	"knnimplicit":  func(params base.Params) core.ModelInterface { return model.NewKNNImplicit(params) },
+	"mf":           func(params base.Params) core.ModelInterface { return NewMatrixFactorization(params) },
+}
+
+//paramsType contains the type expected by gorse for each hyperparameter
+var paramsType = map[base.ParamName]string{
+	base.Lr:            "float",
+	base.Reg:           "float",
+	base.NEpochs:       "int",
+	base.NFactors:      "int",
+	base.RandomState:   "int64",
+	base.UseBias:       "bool",
+	base.InitMean:      "float",
+	base.InitStdDev:    "float",
+	base.InitLow:       "float",
+	base.InitHigh:      "float",
+	base.NUserClusters: "int",
+	base.NItemClusters: "int",
+	base.Type:          "string",
+	base.UserBased:     "bool",
+	base.Similarity:    "string",
+	base.K:             "int",
+	base.MinK:          "int",
+	base.Optimizer:     "string",
+	base.Shrinkage:     "float",
+	base.Alpha:         "float",
+	Implicit:           "bool",
+}
+
+//ParseParam converts a decoded configuration value to the type expected by gorse
+func ParseParam(name string, value interface{}) (interface{}, error) {
+	kind, ok := paramsType[base.ParamName(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameter %s", name)
+	}
+
+	switch kind {
+	case "float":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		}
+	case "int", "int64":
+		var i int
+		switch v := value.(type) {
+		case float64:
+			if v != float64(int(v)) {
+				return nil, fmt.Errorf("parameter %s must be an integer, got %v", name, v)
+			}
+			i = int(v)
+		case int:
+			i = v
+		case int64:
+			i = int(v)
+		default:
+			return nil, fmt.Errorf("parameter %s must be an integer, got %v", name, value)
+		}
+		if kind == "int64" {
+			return int64(i), nil
+		}
+		return i, nil
+	case "bool":
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case "string":
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("parameter %s must be a %s, got %v", name, kind, value)
+}
+
+//ParseParams converts decoded configuration parameters to gorse parameters
+func ParseParams(params map[string]interface{}) (base.Params, error) {
+	parsed := base.Params{}
+	for name, value := range params {
+		v, err := ParseParam(name, value)
+		if err != nil {
+			return nil, err
+		}
+		parsed[base.ParamName(name)] = v
+	}
+
+	return parsed, nil
+}
+
+//DefaultRegistry returns the registry of the models used when no configuration is given, the same as config/models.json
+func DefaultRegistry() *Registry {
+	return &Registry{
+		Models: []ModelConfig{
+			{
+				Name:  "BaseLine",
+				Model: "baseline",
+				Params: map[string]interface{}{
+					"NEpochs": 150,
+					"Lr":      0.1,
+					"Reg":     0.5,
+				},
+			},
+			{
+				Name:  "SlopeOne",
+				Model: "slopeone",
+			},
+			{
+				Name:  "CoClustering",
+				Model: "coclustering",
+				Params: map[string]interface{}{
+					"NEpochs":       150,
+					"NUserClusters": 10,
+					"NItemClusters": 10,
+				},
+			},
+			{
+				Name:  "SVD",
+				Model: "svd",
+				Params: map[string]interface{}{
+					"NEpochs":    500,
+					"Reg":        0.005,
+					"Lr":         0.005,
+					"NFactors":   10,
+					"InitMean":   0,
+					"InitStdDev": 0.01,
+				},
+			},
+			{
+				Name:     "BPR",
+				Model:    "bpr",
+				Feedback: "both",
+				Params: map[string]interface{}{
+					"NEpochs":    150,
+					"NFactors":   50,
+					"Reg":        0.005,
+					"Lr":         0.01,
+					"InitMean":   0,
+					"InitStdDev": 0.001,
+				},
+			},
+			{
+				Name:     "WRMF",
+				Model:    "wrmf",
+				Feedback: "implicit",
+				Params: map[string]interface{}{
+					"NEpochs":  50,
+					"NFactors": 15,
+					"Reg":      0.06,
+					"Alpha":    1,
+				},
+			},
+			{
+				Name:  "MF",
+				Model: "mf",
+				Params: map[string]interface{}{
+					"NEpochs":   50,
+					"NFactors":  10,
+					"Lr":        0.01,
+					"Reg":       0.05,
+					"Optimizer": SGD,
+				},
+			},
+			{
+				Name:     "MF-Implicit",
+				Model:    "mf",
+				Feedback: "implicit",
+				Params: map[string]interface{}{
+					"NEpochs":  15,
+					"NFactors": 10,
+					"Reg":      0.1,
+					"Alpha":    10,
+					"Implicit": true,
+				},
+			},
+			{
+				Name:  "KNN",
+				Model: "knn",
+				Params: map[string]interface{}{
+					"NEpochs":    150,
+					"Type":       base.Baseline,
+					"UserBased":  true,
+					"Similarity": base.MSD,
+					"K":          80,
+					"Lr":         0.005,
+					"Reg":        0.02,
+				},
+			},
+		},
+	}
+}
+
+//ForFeedback returns the registry of the models suited for implicit or explicit feedback
+func (r *Registry) ForFeedback(implicit bool) *Registry {
+	registry := &Registry{}
+	for _, c := range r.Models {
+		if c.Supports(implicit) {
+			registry.Models = append(registry.Models, c)
+		}
+	}
+	return registry
+}
+
+//LoadRegistry loads a registry from a JSON configuration file
+func LoadRegistry(path string) (*Registry, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var registry Registry
+	if err := json.Unmarshal(content, &registry); err != nil {
+		return nil, fmt.Errorf("invalid models configuration %s: %v", path, err)
+	}
+
+	//verify configuration before any training
+	names := make(map[string]bool)
+	for _, c := range registry.Models {
+		if names[c.Name] {
+			return nil, fmt.Errorf("model %s is configured more than once", c.Name)
+		}
+		names[c.Name] = true
+
+		if _, err := c.Build(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &registry, nil
+}
+
+//Build creates the model of the configuration
+func (c ModelConfig) Build() (core.ModelInterface, error) {
+	if c.Name == "" {
+		return nil, fmt.Errorf("model %s has no name", c.Model)
+	}
+
+	switch c.Feedback {
+	case "", "explicit", "implicit", "both":
+	default:
+		return nil, fmt.Errorf("model %s has an unknown feedback %s, must be explicit, implicit or both", c.Name, c.Feedback)
+	}
+
+	constructor, ok := modelConstructors[strings.ToLower(c.Model)]
+	if !ok {
+		return nil, fmt.Errorf("model %s has an unknown kind %s", c.Name, c.Model)
+	}
+
+	params, err := ParseParams(c.Params)
+	if err != nil {
+		return nil, fmt.Errorf("model %s: %v", c.Name, err)
+	}
+
+	return constructor(params), nil
+}
+
+//Build creates the enabled models of the registry
+//when names are given, only these models are created even if they are disabled
+func (r *Registry) Build(names ...string) ([]NamedModel, error) {
+	selected := make(map[string]bool)
+	for _, n := range names {
+		selected[n] = true
+	}
+
+	var models []NamedModel
+	found := make(map[string]bool)
+	for _, c := range r.Models {
+		if len(selected) > 0 && !selected[c.Name] {
+			continue
+		}
+		if len(selected) == 0 && !c.IsEnabled() {
+			continue
+		}
+		found[c.Name] = true
+
+		m, err := c.Build()
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, NamedModel{Name: c.Name, Model: m})
+	}
+
+	//verify all selected models exist
+	for _, n := range names {
+		if !found[n] {
+			return nil, fmt.Errorf("model %s is not in the registry", n)
+		}
+	}
+
+	return models, nil
+}
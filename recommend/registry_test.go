@@ -0,0 +1,142 @@
+package recommend
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zhenghaoz/gorse/base"
+)
+
+func TestLoadRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "models.json")
+	config := `{"models": [
+		{"name": "SVD", "model": "svd", "params": {"NEpochs": 10, "Lr": 0.01, "RandomState": 42}},
+		{"name": "KNN", "model": "knn", "enabled": false, "params": {"UserBased": true, "Similarity": "msd"}}
+	]}`
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//only enabled models by default
+	models, err := registry.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 1 || models[0].Name != "SVD" {
+		t.Fatalf("Enabled models are incorrect, got '%v', want '[SVD]'", models)
+	}
+
+	params := models[0].Model.GetParams()
+	if params.GetInt(base.NEpochs, 0) != 10 || params.GetInt64(base.RandomState, 0) != 42 {
+		t.Errorf("Parameters are incorrect, got '%v'", params)
+	}
+
+	//disabled models can be selected by name
+	models, err = registry.Build("KNN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 1 || models[0].Name != "KNN" {
+		t.Errorf("Selected models are incorrect, got '%v', want '[KNN]'", models)
+	}
+
+	if _, err := registry.Build("SVD", "NMF"); err == nil {
+		t.Error("Expected an error when selecting a model missing from the registry")
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	if _, err := ParseParams(map[string]interface{}{"NEpochs": 1.5}); err == nil {
+		t.Error("Expected an error for a non integer number of epochs")
+	}
+	if _, err := ParseParams(map[string]interface{}{"Unknown": 1.0}); err == nil {
+		t.Error("Expected an error for an unknown parameter")
+	}
+
+	//shipped configuration must stay valid
+	if _, err := LoadRegistry("../config/models.json"); err != nil {
+		t.Error(err)
+	}
+
+	registry := DefaultRegistry()
+	models, err := registry.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != len(registry.Models) {
+		t.Errorf("Default models are incorrect, got '%d' models, want '%d'", len(models), len(registry.Models))
+	}
+}
+
+func TestRegistryBuildSelected(t *testing.T) {
+	//the selected models are the only ones built, wherever they are in the registry
+	for _, names := range [][]string{{"SVD"}, {"BaseLine", "BPR"}, {"KNN"}} {
+		models, err := DefaultRegistry().Build(names...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(models) != len(names) {
+			t.Errorf("Selected models are incorrect, got '%v', want '%v'", models, names)
+			continue
+		}
+		for i, m := range models {
+			if m.Name != names[i] {
+				t.Errorf("Selected models are incorrect, got '%v', want '%v'", models, names)
+				break
+			}
+		}
+	}
+}
+
+func TestDefaultRegistryConfig(t *testing.T) {
+	//the shipped configuration and the default registry define the same models in the same order
+	shipped, err := LoadRegistry("../config/models.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := DefaultRegistry()
+	if len(shipped.Models) != len(defaults.Models) {
+		t.Fatalf("Number of models is incorrect, got '%d', want '%d'", len(shipped.Models), len(defaults.Models))
+	}
+	for i, want := range defaults.Models {
+		got := shipped.Models[i]
+		if got.Name != want.Name || got.Model != want.Model || got.Feedback != want.Feedback || got.IsEnabled() != want.IsEnabled() {
+			t.Errorf("Model %d is incorrect, got '%v', want '%v'", i, got, want)
+			continue
+		}
+		gotParams, err := ParseParams(got.Params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantParams, err := ParseParams(want.Params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(gotParams, wantParams) {
+			t.Errorf("Parameters of %s are incorrect, got '%v', want '%v'", want.Name, gotParams, wantParams)
+		}
+	}
+}
+
+func TestPredictsRatings(t *testing.T) {
+	want := map[string]bool{"BaseLine": true, "SVD": true, "KNN": true, "MF": true, "BPR": false, "WRMF": false, "MF-Implicit": false}
+	for _, c := range DefaultRegistry().Models {
+		if w, ok := want[c.Name]; ok && c.PredictsRatings() != w {
+			t.Errorf("Rating predictions of %s are incorrect, got '%v', want '%v'", c.Name, c.PredictsRatings(), w)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package recommend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+//RecipeFilter returns if a recipe can be recommended
+type RecipeFilter func(recipeID string) bool
+
+//Restrictions contains the diets and allergies of a user, recipes violating them are never recommended
+type Restrictions struct {
+	Diets     []string
+	Allergens []string
+}
+
+//IsEmpty returns if there is no restriction
+func (r Restrictions) IsEmpty() bool {
+	return len(r.Diets) == 0 && len(r.Allergens) == 0
+}
+
+//Merge returns the restrictions of both
+func (r Restrictions) Merge(other Restrictions) Restrictions {
+	return Restrictions{
+		Diets:     mergeNames(r.Diets, other.Diets),
+		Allergens: mergeNames(r.Allergens, other.Allergens),
+	}
+}
+
+func mergeNames(a, b []string) []string {
+	set := make(map[string]bool)
+	var names []string
+	for _, n := range append(append([]string{}, a...), b...) {
+		n = strings.ToLower(strings.TrimSpace(n))
+		if n != "" && !set[n] {
+			set[n] = true
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+//UserRestrictions returns the restrictions of a user from the diet_ and allergen_ columns of the users dataframe
+//e.g. a user with diet_vegetarian and allergen_nuts set to 1 is vegetarian and allergic to nuts
+func UserRestrictions(userID int, users dataframe.DataFrame) Restrictions {
+	var r Restrictions
+	if !hasColumn(users, "id") {
+		return r
+	}
+
+	ids, err := users.Col("id").Int()
+	if err != nil {
+		return r
+	}
+	for i, id := range ids {
+		if id != userID {
+			continue
+		}
+		for _, n := range users.Names() {
+			if users.Col(n).Elem(i).String() != "1" {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(n, "diet_"):
+				r.Diets = append(r.Diets, strings.TrimPrefix(n, "diet_"))
+			case strings.HasPrefix(n, "allergen_"):
+				r.Allergens = append(r.Allergens, strings.TrimPrefix(n, "allergen_"))
+			}
+		}
+		break
+	}
+
+	return r
+}
+
+//AllergenMapping maps allergens to the ingredients containing them and diets to the allergens they exclude
+type AllergenMapping struct {
+	//Allergens contains the keywords of the ingredients containing each allergen or food group (meat, fish...), see util.ContainsCompound
+	Allergens map[string][]string `json:"allergens"`
+	//Exceptions contains the ingredients containing a keyword of an allergen without containing the allergen (kokosmelk for lactose)
+	Exceptions map[string][]string `json:"exceptions,omitempty"`
+	//Diets contains the allergens or food groups excluded by each diet
+	Diets map[string][]string `json:"diets"`
+}
+
+//DefaultAllergenMapping returns the mapping of the Dutch ingredients of the AH recipes used when no configuration is given
+//config/allergens.json must stay equal to it, which TestLoadAllergenMapping verifies
+func DefaultAllergenMapping() *AllergenMapping {
+	return &AllergenMapping{
+		Allergens: map[string][]string{
+			"gluten":    {"tarwe", "bloem", "brood", "pasta", "spaghetti", "penne", "macaroni", "lasagne", "tagliatelle", "noedels", "mie", "couscous", "bulgur", "paneermeel", "tortilla", "wraps", "bladerdeeg", "pizzadeeg", "spelt", "rogge", "gerst", "naan", "pita", "ciabatta", "stokbrood", "beschuit", "crackers"},
+			"nuts":      {"noot", "noten", "amandel", "amandelen", "cashew", "hazelnoot", "walnoot", "pecan", "pistache", "macadamia", "pijnboompitten"},
+			"peanuts":   {"pinda", "pindas", "pindakaas", "satésaus", "satesaus"},
+			"lactose":   {"melk", "kaas", "boter", "room", "slagroom", "kookroom", "yoghurt", "kwark", "mozzarella", "parmezaanse", "parmezaan", "feta", "mascarpone", "ricotta", "crème", "creme", "geitenkaas", "burrata"},
+			"egg":       {"ei", "eieren", "eidooier", "eidooiers", "eiwit", "mayonaise"},
+			"fish":      {"vis", "zalm", "tonijn", "kabeljauw", "makreel", "ansjovis", "haring", "pangasius", "tilapia", "forel", "schol", "vissaus"},
+			"shellfish": {"garnaal", "garnalen", "kreeft", "krab", "mosselen", "inktvis", "scampi", "gamba", "gambas"},
+			"soy":       {"soja", "sojasaus", "tofu", "tempeh", "edamame", "ketjap"},
+			"celery":    {"selderij", "bleekselderij", "knolselderij"},
+			"mustard":   {"mosterd"},
+			"sesame":    {"sesam", "sesamzaad", "sesamolie", "tahin", "tahini"},
+			"meat":      {"vlees", "kip", "rund", "gehakt", "varken", "spek", "ham", "worst", "bacon", "lam", "kalkoen", "chorizo", "salami", "biefstuk", "shoarma", "pancetta", "prosciutto", "krabbetjes", "ossenhaas", "entrecote"},
+			"honey":     {"honing"},
+		},
+		Exceptions: map[string][]string{
+			"gluten":    {"bloemkool", "zonnebloem"},
+			"nuts":      {"nootmuskaat", "kokosnoot"},
+			"lactose":   {"kokosmelk", "kokosroom", "kokosyoghurt", "sojamelk", "sojayoghurt", "amandelmelk", "havermelk", "rijstmelk", "pindakaas", "pindaboter", "cacaoboter"},
+			"egg":       {"prei", "eikenblad"},
+			"fish":      {"inktvis"},
+			"shellfish": {"krabbetjes"},
+			"meat":      {"vleestomaat", "vleestomaten", "hamburgerbroodje", "lamsoor", "spekkoek"},
+			"honey":     {"honingmeloen"},
+		},
+		Diets: map[string][]string{
+			"vegetarian":  {"meat", "fish", "shellfish"},
+			"pescatarian": {"meat"},
+			"vegan":       {"meat", "fish", "shellfish", "lactose", "egg", "honey"},
+			"glutenfree":  {"gluten"},
+			"lactosefree": {"lactose"},
+		},
+	}
+}
+
+//LoadAllergenMapping loads an allergen mapping from a JSON configuration file
+func LoadAllergenMapping(path string) (*AllergenMapping, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var mapping AllergenMapping
+	if err := json.Unmarshal(content, &mapping); err != nil {
+		return nil, fmt.Errorf("invalid allergens configuration %s: %v", path, err)
+	}
+
+	//verify exceptions and diets only concern known allergens
+	for allergen := range mapping.Exceptions {
+		if _, ok := mapping.Allergens[allergen]; !ok {
+			return nil, fmt.Errorf("exceptions of an unknown allergen %s", allergen)
+		}
+	}
+	for diet, allergens := range mapping.Diets {
+		for _, a := range allergens {
+			if _, ok := mapping.Allergens[a]; !ok {
+				return nil, fmt.Errorf("diet %s excludes an unknown allergen %s", diet, a)
+			}
+		}
+	}
+
+	return &mapping, nil
+}
+
+//excluded returns the allergens excluded by restrictions
+func (m *AllergenMapping) excluded(r Restrictions) (map[string]bool, error) {
+	excluded := make(map[string]bool)
+	for _, d := range r.Diets {
+		allergens, ok := m.Diets[strings.ToLower(d)]
+		if !ok {
+			return nil, fmt.Errorf("unknown diet %s", d)
+		}
+		for _, a := range allergens {
+			excluded[a] = true
+		}
+	}
+	for _, a := range r.Allergens {
+		a = strings.ToLower(a)
+		if _, ok := m.Allergens[a]; !ok {
+			return nil, fmt.Errorf("unknown allergen %s", a)
+		}
+		excluded[a] = true
+	}
+
+	return excluded, nil
+}
+
+//containsAllergen returns if an ingredient column (ingredient_ followed by the ingredient name) contains one of the keywords
+//and none of the exceptions
+func containsAllergen(column string, keywords, exceptions []string) bool {
+	return util.ContainsCompound(strings.TrimPrefix(column, "ingredient_"), keywords, exceptions)
+}
+
+//Violations returns the recipes violating restrictions, by recipe id, with the allergens they contain
+func (m *AllergenMapping) Violations(recipes dataframe.DataFrame, r Restrictions) (map[string][]string, error) {
+	excluded, err := m.excluded(r)
+	if err != nil {
+		return nil, err
+	}
+	idColumn := columnIndex(recipes, "id")
+	if idColumn < 0 {
+		return nil, errors.New("recipes need an id column")
+	}
+
+	//ingredients containing an excluded allergen
+	allergenColumns := make(map[int][]string)
+	for j, n := range recipes.Names() {
+		if !strings.HasPrefix(n, "ingredient_") {
+			continue
+		}
+		for a := range excluded {
+			if containsAllergen(n, m.Allergens[a], m.Exceptions[a]) {
+				allergenColumns[j] = append(allergenColumns[j], a)
+			}
+		}
+	}
+
+	violations := make(map[string][]string)
+	if len(allergenColumns) == 0 {
+		return violations, nil
+	}
+	for _, record := range recipes.Records()[1:] {
+		for j, allergens := range allergenColumns {
+			if record[j] == "1" {
+				violations[record[idColumn]] = mergeNames(violations[record[idColumn]], allergens)
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+//Filter returns the filter of the recipes respecting restrictions
+func (m *AllergenMapping) Filter(recipes dataframe.DataFrame, r Restrictions) (RecipeFilter, error) {
+	violations, err := m.Violations(recipes, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(recipeID string) bool {
+		_, violates := violations[recipeID]
+		return !violates
+	}, nil
+}
+
+//allows returns if a filter allows a recipe, a nil filter allows all recipes
+func (f RecipeFilter) allows(recipeID string) bool {
+	return f == nil || f(recipeID)
+}
@@ -0,0 +1,232 @@
+package recommend
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/zhenghaoz/gorse/base"
+)
+
+func TestContainsAllergen(t *testing.T) {
+	mapping := DefaultAllergenMapping()
+	tests := []struct {
+		column   string
+		allergen string
+		want     bool
+	}{
+		{"ingredient_tarwebloem", "gluten", true},
+		{"ingredient_bloemkool", "gluten", false},
+		{"ingredient_kip", "meat", true},
+		{"ingredient_kipfilet", "meat", true},
+		{"ingredient_kipdijfilet", "meat", true},
+		{"ingredient_kipreepjes", "meat", true},
+		{"ingredient_kalkoenfilet", "meat", true},
+		{"ingredient_varkensfilet", "meat", true},
+		{"ingredient_runderriblappen", "meat", true},
+		{"ingredient_hamblokjes", "meat", true},
+		{"ingredient_vleestomaten", "meat", false},
+		{"ingredient_zalmfilet", "fish", true},
+		{"ingredient_kabeljauwfilet", "fish", true},
+		{"ingredient_tonijnsteak", "fish", true},
+		{"ingredient_geraspte_kaas", "lactose", true},
+		{"ingredient_kokosmelk", "lactose", false},
+		{"ingredient_nootmuskaat", "nuts", false},
+		{"ingredient_ei", "egg", true},
+		{"ingredient_prei", "egg", false},
+	}
+
+	for _, test := range tests {
+		if got := containsAllergen(test.column, mapping.Allergens[test.allergen], mapping.Exceptions[test.allergen]); got != test.want {
+			t.Errorf("Allergen %s of %s is incorrect, got '%v', want '%v'", test.allergen, test.column, got, test.want)
+		}
+	}
+
+	//fish compounds are excluded by the vegetarian and pescatarian diets
+	recipes := dataframe.LoadRecords([][]string{
+		{"id", "ingredient_zalmfilet", "ingredient_kipdijfilet"},
+		{"1", "1", "0"},
+		{"2", "0", "1"},
+	})
+	violations, err := mapping.Violations(recipes, Restrictions{Diets: []string{"vegetarian"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 2 {
+		t.Errorf("Vegetarian violations are incorrect, got '%v'", violations)
+	}
+	violations, err = mapping.Violations(recipes, Restrictions{Diets: []string{"pescatarian"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(violations, map[string][]string{"2": {"meat"}}) {
+		t.Errorf("Pescatarian violations are incorrect, got '%v'", violations)
+	}
+
+	//the id column is found by its name
+	recipes = dataframe.LoadRecords([][]string{
+		{"ingredient_zalmfilet", "id"},
+		{"1", "7"},
+		{"0", "8"},
+	})
+	violations, err = mapping.Violations(recipes, Restrictions{Allergens: []string{"fish"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(violations, map[string][]string{"7": {"fish"}}) {
+		t.Errorf("Violations are incorrect, got '%v'", violations)
+	}
+	if _, err := mapping.Violations(recipes.Drop("id"), Restrictions{Allergens: []string{"fish"}}); err == nil {
+		t.Error("Recipes without id should fail")
+	}
+}
+
+func TestUserRestrictions(t *testing.T) {
+	users := dataframe.LoadRecords([][]string{
+		{"id", "diet_vegetarian", "allergen_nuts", "allergen_egg"},
+		{"1", "1", "1", "0"},
+		{"2", "0", "0", "1"},
+	})
+
+	r := UserRestrictions(1, users)
+	if !reflect.DeepEqual(r, Restrictions{Diets: []string{"vegetarian"}, Allergens: []string{"nuts"}}) {
+		t.Errorf("Restrictions are incorrect, got '%v'", r)
+	}
+
+	r = UserRestrictions(2, users).Merge(Restrictions{Allergens: []string{" Nuts", "egg"}})
+	if !reflect.DeepEqual(r, Restrictions{Allergens: []string{"egg", "nuts"}}) {
+		t.Errorf("Merged restrictions are incorrect, got '%v'", r)
+	}
+
+	if !UserRestrictions(3, users).IsEmpty() {
+		t.Error("Unknown user should not have restrictions")
+	}
+}
+
+func TestLoadAllergenMapping(t *testing.T) {
+	mapping, err := LoadAllergenMapping("../config/allergens.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the shipped configuration and the default mapping are the same hard filter
+	defaults := DefaultAllergenMapping()
+	for name, pair := range map[string][2]map[string][]string{
+		"allergens":  {mapping.Allergens, defaults.Allergens},
+		"exceptions": {mapping.Exceptions, defaults.Exceptions},
+		"diets":      {mapping.Diets, defaults.Diets},
+	} {
+		got, want := pair[0], pair[1]
+		for key := range want {
+			if !reflect.DeepEqual(got[key], want[key]) {
+				t.Errorf("%s of %s in the configuration are incorrect, got '%v', want '%v'", name, key, got[key], want[key])
+			}
+		}
+		for key := range got {
+			if _, ok := want[key]; !ok {
+				t.Errorf("%s of %s in the configuration are not in the default mapping", name, key)
+			}
+		}
+	}
+	if !reflect.DeepEqual(mapping, defaults) {
+		t.Error("Allergens configuration should be the default mapping")
+	}
+}
+
+func TestViolations(t *testing.T) {
+	mapping := DefaultAllergenMapping()
+	recipes := testRecipes()
+
+	violations, err := mapping.Violations(recipes, Restrictions{Diets: []string{"vegetarian"}, Allergens: []string{"gluten"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"1": {"gluten"},
+		"2": {"meat"},
+		"3": {"gluten", "meat"},
+	}
+	if !reflect.DeepEqual(violations, want) {
+		t.Errorf("Violations are incorrect, got '%v', want '%v'", violations, want)
+	}
+
+	if _, err := mapping.Filter(recipes, Restrictions{Diets: []string{"carnivore"}}); err == nil {
+		t.Error("Expected an error for an unknown diet")
+	}
+	if _, err := mapping.Filter(recipes, Restrictions{Allergens: []string{"kryptonite"}}); err == nil {
+		t.Error("Expected an error for an unknown allergen")
+	}
+}
+
+//vegetarianFilter allows the recipes 1 and 4 of the test recipes, 2 and 3 contain chicken
+func vegetarianFilter(t *testing.T) RecipeFilter {
+	filter, err := DefaultAllergenMapping().Filter(testRecipes(), Restrictions{Diets: []string{"vegetarian"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filter
+}
+
+func TestContentFilteringRestrictions(t *testing.T) {
+	orders := testOrders()
+	recipes := testRecipes()
+
+	//without restriction user 1 is recommended the chicken pasta similar to its preferred pesto
+	recommendItems, err := recommendedContentFiltering(1, 10, 3, nil, nil, orders, recipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(recommendItems, []int{4, 3, 1, 2}) {
+		t.Errorf("Recommendations are incorrect, got '%v', want '%v'", recommendItems, []int{4, 3, 1, 2})
+	}
+
+	filter := vegetarianFilter(t)
+	recommendItems, err = recommendedContentFiltering(1, 10, 3, filter, nil, orders, recipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recommendItems) == 0 {
+		t.Error("Vegetarian recipes should be recommended")
+	}
+	for _, r := range recommendItems {
+		if !filter(strconv.Itoa(r)) {
+			t.Errorf("Recipe %d violates the restrictions", r)
+		}
+	}
+}
+
+func TestCollaborativeFilteringRestrictions(t *testing.T) {
+	data := ordersDataSet(testOrders())
+	mf := NewMatrixFactorization(base.Params{base.NFactors: 2, base.NEpochs: 10, base.Optimizer: ALS})
+	mf.Fit(data, nil)
+
+	//user 3 only ordered recipe 2
+	if recommendItems := recommendedCollaborativeFiltering("3", 10, mf, data, data, nil, nil); len(recommendItems) != 2 {
+		t.Errorf("Recommendations are incorrect, got '%v', want recipes 1 and 3", recommendItems)
+	}
+
+	recommendItems := recommendedCollaborativeFiltering("3", 10, mf, data, data, vegetarianFilter(t), nil)
+	if !reflect.DeepEqual(recommendItems, []string{"1"}) {
+		t.Errorf("Recommendations are incorrect, got '%v', want '%v'", recommendItems, []string{"1"})
+	}
+}
+
+func TestOnlineRecommenderRestrictions(t *testing.T) {
+	orders := testOrders()
+	mf := NewMatrixFactorization(base.Params{base.NFactors: 2, base.NEpochs: 10, base.Optimizer: ALS})
+	mf.Fit(ordersDataSet(orders), nil)
+
+	online, err := NewOnlineRecommender(mf, orders, testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := online.AddOrder(4, 1, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	recommendItems := online.Recommend(4, 10, vegetarianFilter(t), nil)
+	if !reflect.DeepEqual(recommendItems, []string{"4"}) {
+		t.Errorf("Recommendations are incorrect, got '%v', want '%v'", recommendItems, []string{"4"})
+	}
+}
@@ -0,0 +1,308 @@
+package recommend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/core"
+)
+
+//SearchSpace contains the hyperparameters to search for a model
+type SearchSpace struct {
+	//Name identifies the model in the search results
+	Name string `json:"name"`
+	//Model is the kind of model (see modelConstructors)
+	Model string `json:"model"`
+	//Params contains the fixed hyperparameters of the model
+	Params map[string]interface{} `json:"params,omitempty"`
+	//Grid contains the candidate values of the searched hyperparameters
+	Grid map[string][]interface{} `json:"grid"`
+}
+
+//SearchConfig defines how the hyperparameters are searched
+type SearchConfig struct {
+	//Method is the search method: grid, random or halving (successive halving)
+	Method string `json:"method"`
+	//Trials is the number of random candidates evaluated by the random search and the successive halving
+	Trials int `json:"trials"`
+	//Metric is the metric optimized by the early stopping and the successive halving: precision, recall or rmse
+	Metric string `json:"metric"`
+	//Patience stops the random search after this number of trials without improvement, never stops when 0
+	Patience int `json:"patience"`
+	//Eta is the reduction factor of the successive halving, 1/eta of the candidates are kept at each round
+	Eta int `json:"eta"`
+	//MinEpochs is the number of epochs of the first successive halving round
+	MinEpochs int `json:"minEpochs"`
+	//MaxEpochs is the maximal number of epochs of the successive halving rounds
+	MaxEpochs int `json:"maxEpochs"`
+	//TrialLog is the file in which the evaluated trials are logged, a search is resumed from its log
+	TrialLog string `json:"trialLog"`
+	//CV is the cross validation: kfold or temporal
+	CV string `json:"cv"`
+	//Folds is the number of cross validation folds
+	Folds int `json:"folds"`
+	//NbRecipes is the number of recommended recipes used by the ranking metrics
+	NbRecipes int `json:"nbRecipes"`
+	//Seed seeds the folds and the random search
+	Seed int64 `json:"seed"`
+}
+
+//SearchFile contains a parameter space file
+type SearchFile struct {
+	SearchConfig
+	Models []SearchSpace `json:"models"`
+}
+
+//SearchResult contains the best hyperparameters of a model for a metric
+type SearchResult struct {
+	Name   string
+	Model  string
+	Metric string
+	Score  float64
+	Params base.Params
+}
+
+//searchMetrics are the metrics returned by the search evaluators, in the same order
+var searchMetrics = []string{"precision", "recall", "rmse"}
+
+//LoadSearchFile loads a parameter space file
+func LoadSearchFile(path string) (*SearchFile, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	//defaults
+	file := SearchFile{
+		SearchConfig: SearchConfig{
+			Method:    "grid",
+			Trials:    10,
+			Metric:    "precision",
+			Eta:       3,
+			MinEpochs: 5,
+			MaxEpochs: 100,
+			CV:        "kfold",
+			Folds:     5,
+			NbRecipes: 10,
+		},
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("invalid parameter space %s: %v", path, err)
+	}
+
+	for _, s := range file.Models {
+		if _, err := s.parameterGrid(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &file, nil
+}
+
+//parameterGrid converts the searched values to gorse parameters
+func (s SearchSpace) parameterGrid() (core.ParameterGrid, error) {
+	grid := core.ParameterGrid{}
+	for name, values := range s.Grid {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("model %s has no candidate for %s", s.Name, name)
+		}
+
+		for _, v := range values {
+			parsed, err := ParseParam(name, v)
+			if err != nil {
+				return nil, fmt.Errorf("model %s: %v", s.Name, err)
+			}
+			grid[base.ParamName(name)] = append(grid[base.ParamName(name)], parsed)
+		}
+	}
+
+	return grid, nil
+}
+
+//NewTemporalSplitter creates a splitter with expanding time windows
+//orders are divided chronologically in k+1 blocks, fold i trains on the i first blocks and tests on the next one
+//the dataset given to the splitter must be the dataset built from orders
+func NewTemporalSplitter(orders dataframe.DataFrame, k int) (core.Splitter, error) {
+	dates, err := ordersDate(orders)
+	if err != nil {
+		return nil, err
+	}
+	if len(dates) < k+1 {
+		return nil, fmt.Errorf("%d orders are not enough for %d temporal folds", len(dates), k)
+	}
+
+	//chronological order of the orders
+	perm := make([]int, len(dates))
+	for i := range perm {
+		perm[i] = i
+	}
+	sort.SliceStable(perm, func(i, j int) bool {
+		return dates[perm[i]].Before(dates[perm[j]])
+	})
+
+	return func(dataSet core.DataSetInterface, _ int64) (trainFolds, testFolds []core.DataSetInterface) {
+		trainFolds = make([]core.DataSetInterface, k)
+		testFolds = make([]core.DataSetInterface, k)
+		blockSize := len(perm) / (k + 1)
+		for i := 0; i < k; i++ {
+			end := (i + 1) * blockSize
+			testEnd := end + blockSize
+			if i == k-1 {
+				testEnd = len(perm)
+			}
+			trainFolds[i] = dataSet.SubSet(perm[:end])
+			testFolds[i] = dataSet.SubSet(perm[end:testEnd])
+		}
+		return trainFolds, testFolds
+	}, nil
+}
+
+//splitter creates the cross validation splitter of the configuration
+func (c SearchConfig) splitter(orders dataframe.DataFrame) (core.Splitter, error) {
+	switch c.CV {
+	case "kfold":
+		return core.NewKFoldSplitter(c.Folds), nil
+	case "temporal":
+		return NewTemporalSplitter(orders, c.Folds)
+	default:
+		return nil, fmt.Errorf("unknown cross validation %s, must be kfold or temporal", c.CV)
+	}
+}
+
+//Search searches the best hyperparameters of a model for each metric
+//evaluated trials are looked up and logged in trials
+func Search(space SearchSpace, config SearchConfig, orders dataframe.DataFrame, trials *TrialLog) ([]SearchResult, error) {
+	log.Printf("Searching hyperparameters of %s (%s search, %s cross validation)...\n", space.Name, config.Method, config.CV)
+
+	estimator, err := ModelConfig{Name: space.Name, Model: space.Model, Params: space.Params}.Build()
+	if err != nil {
+		return nil, err
+	}
+	grid, err := space.parameterGrid()
+	if err != nil {
+		return nil, err
+	}
+	splitter, err := config.splitter(orders)
+	if err != nil {
+		return nil, err
+	}
+	if metricIndex(config.Metric) < 0 {
+		return nil, fmt.Errorf("unknown metric %s, must be one of %v", config.Metric, searchMetrics)
+	}
+
+	t := &tuner{
+		space:     space,
+		config:    config,
+		estimator: estimator,
+		fixed:     estimator.GetParams().Copy(),
+		data:      ordersDataSet(orders),
+		splitter:  splitter,
+		trials:    trials,
+		setting:   searchSetting(config, orders),
+	}
+
+	var evaluated []Trial
+	switch config.Method {
+	case "grid":
+		evaluated, err = t.gridSearch(grid)
+	case "random":
+		evaluated, err = t.randomSearch(grid)
+	case "halving":
+		evaluated, err = t.successiveHalving(grid)
+	default:
+		return nil, fmt.Errorf("unknown search method %s, must be grid, random or halving", config.Method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(evaluated) == 0 {
+		return nil, fmt.Errorf("no candidate evaluated for %s", space.Name)
+	}
+
+	//best trial of each metric
+	results := make([]SearchResult, len(searchMetrics))
+	for i, metric := range searchMetrics {
+		best := evaluated[0]
+		for _, trial := range evaluated[1:] {
+			if better(metric, trial.Scores[metric], best.Scores[metric]) {
+				best = trial
+			}
+		}
+
+		params, err := ParseParams(best.Params)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = SearchResult{
+			Name:   space.Name,
+			Model:  space.Model,
+			Metric: metric,
+			Score:  best.Scores[metric],
+			Params: params,
+		}
+	}
+
+	return results, nil
+}
+
+//SearchAll searches the best hyperparameters of all the models of a parameter space file
+func SearchAll(file *SearchFile, orders dataframe.DataFrame) ([]SearchResult, error) {
+	start := time.Now()
+
+	trials, err := OpenTrialLog(file.TrialLog)
+	if err != nil {
+		return nil, err
+	}
+	defer trials.Close()
+
+	var results []SearchResult
+	for _, s := range file.Models {
+		r, err := Search(s, file.SearchConfig, orders, trials)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r...)
+	}
+
+	log.Printf("Hyperparameters search done in %s\n", time.Since(start).Round(time.Second))
+
+	return results, nil
+}
+
+//BestParamsRegistry converts the search results as a registry with a model per metric
+//models are named name@metric
+func BestParamsRegistry(results []SearchResult) *Registry {
+	registry := &Registry{}
+	for _, r := range results {
+		params := make(map[string]interface{}, len(r.Params))
+		for name, value := range r.Params {
+			params[string(name)] = value
+		}
+
+		registry.Models = append(registry.Models, ModelConfig{
+			Name:   fmt.Sprintf("%s@%s", r.Name, r.Metric),
+			Model:  r.Model,
+			Params: params,
+		})
+	}
+
+	return registry
+}
+
+//WriteRegistry writes a registry as a JSON configuration file
+func WriteRegistry(registry *Registry, path string) error {
+	log.Printf("Writing models configuration in %s...\n", path)
+
+	content, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(content, '\n'), 0644)
+}
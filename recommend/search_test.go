@@ -0,0 +1,213 @@
+package recommend
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+//testManyOrders generates deterministic dated orders of nbUsers users on nbRecipes recipes
+func testManyOrders(nbUsers, nbRecipes int) dataframe.DataFrame {
+	records := [][]string{{"user_id", "recipe_id", "rating", "date"}}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for u := 1; u <= nbUsers; u++ {
+		for r := 1; r <= nbRecipes; r++ {
+			if (u+r)%3 == 0 {
+				continue
+			}
+			date := start.Add(time.Duration(u*nbRecipes+r) * time.Hour)
+			records = append(records, []string{
+				fmt.Sprint(u), fmt.Sprint(r), fmt.Sprint((u*r)%5 + 1), date.Format(util.DateLayout),
+			})
+		}
+	}
+
+	return dataframe.LoadRecords(records)
+}
+
+func TestNewTemporalSplitter(t *testing.T) {
+	orders := testManyOrders(10, 8)
+	splitter, err := NewTemporalSplitter(orders, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trainFolds, testFolds := splitter(ordersDataSet(orders), 0)
+	if len(trainFolds) != 3 || len(testFolds) != 3 {
+		t.Fatalf("Number of folds is incorrect, got '%d', want '3'", len(trainFolds))
+	}
+
+	for i := 1; i < len(trainFolds); i++ {
+		if trainFolds[i].Count() <= trainFolds[i-1].Count() {
+			t.Errorf("Training window of fold %d should be larger than the previous one", i)
+		}
+	}
+
+	//last fold tests on the remaining orders
+	last := len(trainFolds) - 1
+	if trainFolds[last].Count()+testFolds[last].Count() != orders.Nrow() {
+		t.Errorf("Last fold is incorrect, got '%d' orders, want '%d'", trainFolds[last].Count()+testFolds[last].Count(), orders.Nrow())
+	}
+}
+
+func TestSearch(t *testing.T) {
+	orders := testManyOrders(10, 8)
+	space := SearchSpace{
+		Name:   "BaseLine",
+		Model:  "baseline",
+		Params: map[string]interface{}{"NEpochs": 5},
+		Grid:   map[string][]interface{}{"Reg": {0.01, 0.1}},
+	}
+
+	for _, cv := range []string{"kfold", "temporal"} {
+		trials, err := OpenTrialLog("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := Search(space, SearchConfig{Method: "grid", Metric: "rmse", CV: cv, Folds: 2, NbRecipes: 3}, orders, trials)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != len(searchMetrics) {
+			t.Fatalf("Number of results is incorrect, got '%d', want '%d'", len(results), len(searchMetrics))
+		}
+		for _, r := range results {
+			if _, ok := r.Params["NEpochs"]; !ok {
+				t.Errorf("Fixed parameters are missing from the best parameters of %s", r.Metric)
+			}
+		}
+
+		//best parameters can be loaded by the registry
+		dir, err := ioutil.TempDir("", "search")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "best.json")
+		if err := WriteRegistry(BestParamsRegistry(results), path); err != nil {
+			t.Fatal(err)
+		}
+		registry, err := LoadRegistry(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := registry.Build("BaseLine@rmse"); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := LoadSearchFile("../config/search.json"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSuccessiveHalving(t *testing.T) {
+	orders := testManyOrders(10, 8)
+	space := SearchSpace{
+		Name:  "SVD",
+		Model: "svd",
+		Grid:  map[string][]interface{}{"Reg": {0.005, 0.05, 0.5}, "NFactors": {2, 5}},
+	}
+	config := SearchConfig{Method: "halving", Metric: "rmse", Trials: 4, Eta: 2, MinEpochs: 2, MaxEpochs: 8, CV: "kfold", Folds: 2, NbRecipes: 3}
+
+	dir, err := ioutil.TempDir("", "halving")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "trials.jsonl")
+
+	trials, err := OpenTrialLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := Search(space, config, orders, trials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trials.Close()
+
+	//rounds of 4, 2 and 1 candidates
+	if len(trials.trials) != 7 {
+		t.Errorf("Number of trials is incorrect, got '%d', want '7'", len(trials.trials))
+	}
+	for _, r := range results {
+		if r.Params.GetInt("NEpochs", 0) != 8 {
+			t.Errorf("Best candidate should be trained with the maximal number of epochs, got '%v'", r.Params)
+		}
+	}
+
+	//search is resumed from the log without new trials
+	resumed, err := OpenTrialLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Close()
+	if len(resumed.trials) != 7 {
+		t.Fatalf("Number of logged trials is incorrect, got '%d', want '7'", len(resumed.trials))
+	}
+	again, err := Search(space, config, orders, resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resumed.trials) != 7 {
+		t.Errorf("Resumed search should not evaluate new trials, got '%d' trials", len(resumed.trials))
+	}
+	for i := range again {
+		if again[i].Score != results[i].Score {
+			t.Errorf("Resumed search result is incorrect, got '%f', want '%f'", again[i].Score, results[i].Score)
+		}
+	}
+
+	//trials logged with another cross validation or other orders are evaluated again
+	config.Folds = 3
+	if _, err := Search(space, config, orders, resumed); err != nil {
+		t.Fatal(err)
+	}
+	if len(resumed.trials) != 14 {
+		t.Errorf("Search with other folds should evaluate new trials, got '%d' trials, want '14'", len(resumed.trials))
+	}
+	if _, err := Search(space, config, testManyOrders(12, 8), resumed); err != nil {
+		t.Fatal(err)
+	}
+	if len(resumed.trials) != 21 {
+		t.Errorf("Search on other orders should evaluate new trials, got '%d' trials, want '21'", len(resumed.trials))
+	}
+}
+
+func TestRandomSearchEarlyStopping(t *testing.T) {
+	orders := testManyOrders(10, 8)
+	//a single candidate never improves after the first trial
+	space := SearchSpace{
+		Name:  "BaseLine",
+		Model: "baseline",
+		Grid:  map[string][]interface{}{"Reg": {0.1}, "NEpochs": {5}},
+	}
+	config := SearchConfig{Method: "random", Metric: "precision", Trials: 10, Patience: 2, CV: "kfold", Folds: 2, NbRecipes: 3}
+
+	trials, err := OpenTrialLog("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tuner := &tuner{space: space, config: config, trials: trials}
+	tuner.estimator, _ = ModelConfig{Name: space.Name, Model: space.Model}.Build()
+	tuner.fixed = tuner.estimator.GetParams().Copy()
+	tuner.data = ordersDataSet(orders)
+	tuner.splitter, _ = config.splitter(orders)
+	grid, _ := space.parameterGrid()
+
+	evaluated, err := tuner.randomSearch(grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evaluated) != 3 {
+		t.Errorf("Random search should stop after patience is exhausted, got '%d' trials, want '3'", len(evaluated))
+	}
+}
@@ -1,178 +1,364 @@
 package recommend
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
 	"github.com/go-gota/gota/dataframe"
-	"github.com/go-gota/gota/series"
 	"github.com/julienrbrt/ut_research_project/util"
+	"github.com/zhenghaoz/gorse/base"
 	"github.com/zhenghaoz/gorse/core"
+	"gonum.org/v1/gonum/stat"
 )
 
-//UsersCloseByXKm returns a dataframe containings users around user with userID from x km
+//UsersCloseByXKm returns a dataframe containings users around user with userID from x km, from the closest to the farthest
+//the users are indexed at each call, use a UsersIndex for repeated queries
 func UsersCloseByXKm(userID int, km float64, users dataframe.DataFrame) dataframe.DataFrame {
-	//get user
-	user := users.Filter(dataframe.F{Colname: "id", Comparator: series.Eq, Comparando: userID})
-	if user.Nrow() == 0 {
+	index, err := NewUsersIndex(users)
+	if err != nil {
 		return dataframe.DataFrame{}
 	}
 
-	//get user's position bounding coordinates
-	boundingCoordinates := util.BoundingCoordinates(user.Col("latitude").Elem(0).Float(), user.Col("longitude").Elem(0).Float(), km)
+	return index.CloseByXKm(userID, km)
+}
+
+//RecipeProfiles contains the tags and ingredients vector of each recipe
+type RecipeProfiles map[string][]float64
+
+//NewRecipeProfiles creates the profiles of the recipes from their tag_ and ingredient_ columns
+func NewRecipeProfiles(recipes dataframe.DataFrame) (RecipeProfiles, error) {
+	var columns []string
+	for _, n := range recipes.Names() {
+		if strings.Contains(n, "tag_") || strings.Contains(n, "ingredient_") {
+			columns = append(columns, n)
+		}
+	}
 
-	matchingUsers := users.
-		FilterAggregation(
-			dataframe.And,
-			dataframe.F{Colname: "id", Comparator: series.Neq, Comparando: userID}, // do not include same user
-			dataframe.F{Colname: "latitude", Comparator: series.GreaterEq, Comparando: boundingCoordinates[0].Latitude},
-			dataframe.F{Colname: "longitude", Comparator: series.GreaterEq, Comparando: boundingCoordinates[0].Longitude},
-			dataframe.F{Colname: "latitude", Comparator: series.LessEq, Comparando: boundingCoordinates[1].Latitude},
-			dataframe.F{Colname: "longitude", Comparator: series.LessEq, Comparando: boundingCoordinates[1].Longitude},
-		)
+	if !hasColumn(recipes, "id") {
+		return nil, errors.New("recipes need an id column")
+	}
+
+	profiles := make(RecipeProfiles, recipes.Nrow())
+	ids := recipes.Col("id").Records()
+	if len(columns) == 0 {
+		for _, id := range ids {
+			profiles[id] = nil
+		}
+		return profiles, nil
+	}
+	for i, record := range recipes.Select(columns).Records()[1:] {
+		profile, err := util.SS2SF(record)
+		if err != nil {
+			return nil, fmt.Errorf("profile of recipe %s: %v", ids[i], err)
+		}
+		profiles[ids[i]] = profile
+	}
 
-	return matchingUsers
+	return profiles, nil
 }
 
-//MeasureCollaborativeSellability measures the sellability using the cosine similarity of target users recommendation to neighboring users recommendation
-func MeasureCollaborativeSellability(userID, nbRecipes int, recommendations []string, m core.ModelInterface, data *core.DataSet, train, test core.DataSetInterface, users, recipes dataframe.DataFrame) float64 {
-	if users.Nrow() == 0 {
+//similarity returns the cosine similarity of two recipes, 1 for the same recipe and 0 when a profile is missing or empty
+func (p RecipeProfiles) similarity(a, b string) float64 {
+	if a == b {
 		return 1
 	}
+	sim, err := util.CosineSimilarity(p[a], p[b])
+	if err != nil {
+		return 0
+	}
 
-	//keep only relevant recipes columns
-	var columnsToDrop []string
-	for _, n := range recipes.Names() {
-		//keep only orders columns and recipes tags
-		if n != "id" && !strings.Contains(n, "tag_") && !strings.Contains(n, "ingredient_") {
-			columnsToDrop = append(columnsToDrop, n)
-		}
+	return sim
+}
+
+//Coverage returns how well the recipes of others cover the recipes: the mean over the recipes of their highest similarity
+//to a recipe of others, 1 when all recipes are in others and 0 when recipes or others are empty
+func (p RecipeProfiles) Coverage(recipes, others []string) float64 {
+	if len(recipes) == 0 {
+		return 0
 	}
-	recipes = recipes.Drop(columnsToDrop)
 
-	//get item profile of recommendation
-	var err error
-	recommendationsProfile := make([][]float64, len(recommendations))
-	for i, r := range recommendations {
-		recommendationsProfile[i], err = util.SS2SF(recipes.Filter(dataframe.F{Colname: "id", Comparator: series.Eq, Comparando: r}).Records()[1][1:])
-		if err != nil {
-			continue
-		}
-	}
-
-	sellability := 0.0
-	for _, id := range users.Col("id").Records() {
-		//fit model
-		m.Fit(train, nil)
-		//generate recommendations for user
-		//get all items in the full dataset
-		items := core.Items(data)
-		//get user ratings in the training set
-		excludeItems := train.User(id)
-		//get top recommended items (excluding rated items)
-		recommendItems, _ := core.Top(items, id, nbRecipes, excludeItems, m)
-
-		//get item profile of neighbors recommendation
-		neighborsRecommendationsProfile := make([][]float64, len(recommendItems))
-		for i, r := range recommendItems {
-			neighborsRecommendationsProfile[i], err = util.SS2SF(recipes.Filter(dataframe.F{Colname: "id", Comparator: series.Eq, Comparando: r}).Records()[1][1:])
-			if err != nil {
-				continue
+	sum := 0.0
+	for _, r := range recipes {
+		best := 0.0
+		for _, o := range others {
+			if sim := p.similarity(r, o); sim > best {
+				best = sim
 			}
 		}
+		sum += best
+	}
 
-		//keep max cosine similarity of a recipe
-		var sim, newSim, meanSim float64
-		for i := range recommendationsProfile {
-			for j := range neighborsRecommendationsProfile {
-				newSim, err = util.CosineSimilarity(recommendationsProfile[i], neighborsRecommendationsProfile[j])
-				if err != nil {
-					continue
-				}
+	return sum / float64(len(recipes))
+}
 
-				if newSim > sim {
-					sim = newSim
-				}
-			}
+//Recommender returns the recommendations of a user
+type Recommender func(userID string) ([]string, error)
 
-			meanSim = +sim
+//CollaborativeRecommender returns the recommendations of a collaborative filtering model already fitted on train
+//(see recommendedCollaborativeFiltering), the model is not fitted again for each user
+func CollaborativeRecommender(nbRecipes int, m core.ModelInterface, data *core.DataSet, train core.DataSetInterface) Recommender {
+	return func(userID string) ([]string, error) {
+		//users without orders have no recommendation
+		if data.UserIndexer().ToIndex(userID) == base.NotId {
+			return nil, nil
 		}
+		return recommendedCollaborativeFiltering(userID, nbRecipes, m, data, train, nil, nil), nil
+	}
+}
 
-		sellability = sellability + (meanSim / float64(len(recommendationsProfile)))
+//ContentRecommender returns the recommendations of content filtering (see recommendedContentFiltering)
+//the similarity of the recipes is calculated once for all users
+func ContentRecommender(nbRecipes, nbTags int, orders, recipes dataframe.DataFrame) (Recommender, error) {
+	sim, err := contentSimilarity(recipes)
+	if err != nil {
+		return nil, err
+	}
+	customers := make(map[string]bool)
+	for _, id := range orders.Col("user_id").Records() {
+		customers[id] = true
 	}
 
-	//mean cosine similarity of all users
-	sellability = sellability / float64(users.Nrow())
+	return func(userID string) ([]string, error) {
+		id, err := strconv.Atoi(userID)
+		if err != nil {
+			return nil, err
+		}
+		//users without orders have no recommendation
+		if !customers[userID] {
+			return nil, nil
+		}
+		ids, err := similarRecipes(id, nbRecipes, nbTags, nil, nil, sim, orders, recipes)
+		if err != nil {
+			return nil, err
+		}
 
-	return sellability
+		recommendItems := make([]string, len(ids))
+		for i, r := range ids {
+			recommendItems[i] = strconv.Itoa(r)
+		}
+		return recommendItems, nil
+	}, nil
 }
 
-//MeasureContentSellability measures the sellability using the cosine similarity of target users recommendation to neighboring users recommendation
-func MeasureContentSellability(userID, nbRecipes, nbTags int, recommendations []int, users, orders, recipes dataframe.DataFrame) float64 {
-	if users.Nrow() == 0 {
+//NeighborWeight returns the weight of a neighbor in the sellability, a nil NeighborWeight weights all neighbors 1
+type NeighborWeight func(userID string) float64
+
+func (w NeighborWeight) of(userID string) float64 {
+	if w == nil {
 		return 1
 	}
+	return w(userID)
+}
+
+//NeighborSellability is the sellability of the recommendations of a user to one of its neighbors
+type NeighborSellability struct {
+	UserID string
+	Weight float64
+	Score  float64
+}
+
+//Sellability is the sellability of the recommendations of a user to its neighbors
+type Sellability struct {
+	Score     float64
+	Neighbors []NeighborSellability
+}
+
+//MeasureSellability measures how well the recommendations of a user would sell to its neighbors, the customers of its meals
+//the score of a neighbor is the coverage of the recommendations by the recipes recommended to the neighbor
+//(see RecipeProfiles.Coverage), between 0 and 1 for recipes with non negative profiles
+//the sellability is the mean of the scores weighted by weight, 0 without neighbors or when all weights are 0
+//the recommendations of the neighbors are given by recommender, so any model family has the same semantics
+func MeasureSellability(recommendations []string, neighborsUsers dataframe.DataFrame, recommender Recommender, profiles RecipeProfiles, weight NeighborWeight) (Sellability, error) {
+	var sellability Sellability
+	if neighborsUsers.Nrow() == 0 {
+		return sellability, nil
+	}
 
-	//keep only relevant recipes columns
-	var columnsToDrop []string
-	for _, n := range recipes.Names() {
-		//keep only orders columns and recipes tags
-		if n != "id" && !strings.Contains(n, "tag_") && !strings.Contains(n, "ingredient_") {
-			columnsToDrop = append(columnsToDrop, n)
+	totalWeight := 0.0
+	for _, id := range neighborsUsers.Col("id").Records() {
+		w := weight.of(id)
+		if w < 0 {
+			return Sellability{}, fmt.Errorf("weight of neighbor %s is negative", id)
 		}
-	}
-	recipes = recipes.Drop(columnsToDrop)
 
-	//get item profile of recommendation
-	var err error
-	recommendationsProfile := make([][]float64, len(recommendations))
-	for i, r := range recommendations {
-		recommendationsProfile[i], err = util.SS2SF(recipes.Filter(dataframe.F{Colname: "id", Comparator: series.Eq, Comparando: r}).Records()[1][1:])
+		neighborRecommendations, err := recommender(id)
 		if err != nil {
-			continue
+			return Sellability{}, fmt.Errorf("recommendations of neighbor %s: %v", id, err)
+		}
+
+		score := profiles.Coverage(recommendations, neighborRecommendations)
+		sellability.Neighbors = append(sellability.Neighbors, NeighborSellability{UserID: id, Weight: w, Score: score})
+		sellability.Score += w * score
+		totalWeight += w
+	}
+
+	if totalWeight > 0 {
+		sellability.Score /= totalWeight
+	} else {
+		sellability.Score = 0
+	}
+
+	return sellability, nil
+}
+
+//Kernel defines how the weight of a neighbor in the sellability decays with its distance to the user
+type Kernel string
+
+const (
+	//UniformKernel weights all neighbors 1
+	UniformKernel Kernel = "uniform"
+	//LinearKernel weights neighbors 1 - distance/radius, 0 beyond the radius
+	LinearKernel Kernel = "linear"
+	//GaussianKernel weights neighbors exp(-distance²/2σ²) with σ = radius/2
+	GaussianKernel Kernel = "gaussian"
+	//StepKernel weights neighbors 1 in half the radius and 0 beyond
+	StepKernel Kernel = "step"
+)
+
+//ParseKernel returns the kernel of a name, uniform, linear, gaussian or step
+func ParseKernel(name string) (Kernel, error) {
+	switch k := Kernel(name); k {
+	case UniformKernel, LinearKernel, GaussianKernel, StepKernel:
+		return k, nil
+	}
+
+	return "", fmt.Errorf("unknown kernel %q, must be uniform, linear, gaussian or step", name)
+}
+
+//weight returns the weight of a neighbor at distance km for a radius in km
+func (k Kernel) weight(distance, radius float64) float64 {
+	switch k {
+	case LinearKernel:
+		return math.Max(0, 1-distance/radius)
+	case GaussianKernel:
+		sigma := radius / 2
+		return math.Exp(-distance * distance / (2 * sigma * sigma))
+	case StepKernel:
+		if distance <= radius/2 {
+			return 1
 		}
+		return 0
+	}
+
+	return 1
+}
+
+//DistanceWeight returns the weight of the neighbors of a user decaying with their distance in a straight line
+//(util.DistanceTo) with the kernel, so the neighbors close enough to pick up a meal count more
+//radius is in km, neighbors without location have no weight
+func DistanceWeight(userID int, kernel Kernel, radius float64, users dataframe.DataFrame) (NeighborWeight, error) {
+	if _, err := ParseKernel(string(kernel)); err != nil {
+		return nil, err
+	}
+	if kernel == UniformKernel {
+		return nil, nil
+	}
+	if radius <= 0 {
+		return nil, errors.New("radius of the distance weight must be positive")
+	}
+
+	index, err := NewUsersIndex(users)
+	if err != nil {
+		return nil, err
+	}
+
+	return index.distanceWeight(userID, kernel, radius)
+}
+
+//distanceWeight returns the DistanceWeight of the neighbors of a user located in the index
+func (index *UsersIndex) distanceWeight(userID int, kernel Kernel, radius float64) (NeighborWeight, error) {
+	if kernel == UniformKernel {
+		return nil, nil
+	}
+
+	center, ok := index.location(userID)
+	if !ok {
+		return nil, fmt.Errorf("user %d has no location", userID)
 	}
 
-	sellability := 0.0
-	for _, id := range users.Col("id").Records() {
-		sid, _ := strconv.Atoi(id)
-		recommendItems, err := recommendedContentFiltering(sid, nbRecipes, nbTags, orders, recipes)
+	return func(neighborID string) float64 {
+		id, err := strconv.Atoi(neighborID)
 		if err != nil {
-			continue
+			return 0
+		}
+		l, ok := index.location(id)
+		if !ok {
+			return 0
 		}
+		return kernel.weight(util.DistanceTo(center.Latitude, center.Longitude, l.Latitude, l.Longitude), radius)
+	}, nil
+}
 
-		//get item profile of neighbors recommendation
-		neighborsRecommendationsProfile := make([][]float64, len(recommendItems))
-		for i, r := range recommendItems {
-			neighborsRecommendationsProfile[i], err = util.SS2SF(recipes.Filter(dataframe.F{Colname: "id", Comparator: series.Eq, Comparando: r}).Records()[1][1:])
-			if err != nil {
-				continue
-			}
+//LocalAppeal returns the appeal of each recipe to the neighbors of a user, the sellability of the recipe alone
+//(see MeasureSellability): the weighted mean over the neighbors of its highest similarity to a recipe recommended to them
+func LocalAppeal(recipeIDs []string, neighborsUsers dataframe.DataFrame, recommender Recommender, profiles RecipeProfiles, weight NeighborWeight) (map[string]float64, error) {
+	//the recommendations of the neighbors are computed once for all recipes
+	recommender = cachedRecommender(recommender)
+
+	appeal := make(map[string]float64, len(recipeIDs))
+	for _, id := range recipeIDs {
+		sellability, err := MeasureSellability([]string{id}, neighborsUsers, recommender, profiles, weight)
+		if err != nil {
+			return nil, err
 		}
+		appeal[id] = sellability.Score
+	}
+
+	return appeal, nil
+}
 
-		//keep max cosine similarity of a recipe
-		var sim, newSim, meanSim float64
-		for i := range recommendationsProfile {
-			for j := range neighborsRecommendationsProfile {
-				newSim, err = util.CosineSimilarity(recommendationsProfile[i], neighborsRecommendationsProfile[j])
-				if err != nil {
-					fmt.Println(err)
-					continue
-				}
+//SellabilityReranker returns the reranker trading off the score of the recommendations against their local appeal (MMR-like)
+//the recipes are ranked by lambda·z(score) + (1-lambda)·z(appeal), with z the standardized values, so lambda = 1 keeps the
+//ranking (nil reranker) and a lower lambda prefers the recipes that can be shared with the neighbors, recipes without appeal get no bonus
+func SellabilityReranker(lambda float64, appeal map[string]float64) (Reranker, error) {
+	if lambda <= 0 || lambda > 1 {
+		return nil, errors.New("lambda of the sellability reranking must be in (0, 1]")
+	}
+	if lambda == 1 || len(appeal) == 0 {
+		return nil, nil
+	}
 
-				if newSim > sim {
-					sim = newSim
-				}
-			}
+	values := make([]float64, 0, len(appeal))
+	for _, a := range appeal {
+		values = append(values, a)
+	}
+	mean, std := stat.MeanStdDev(values, nil)
+	if !(std > 0) {
+		return nil, nil
+	}
 
-			meanSim = +sim
+	//the scores are standardized by rerank, dividing by lambda keeps the ranking
+	factor := (1 - lambda) / lambda
+	return func(recipeID string) float64 {
+		a, ok := appeal[recipeID]
+		if !ok {
+			return 0
 		}
+		return factor * (a - mean) / std
+	}, nil
+}
 
-		sellability = sellability + (meanSim / float64(len(recommendationsProfile)))
+//localReranker returns the reranker combined with the SellabilityReranker of the recipes for the neighbors of the user
+//whose recommendations are given by recommender, lambda = 1 keeps the reranker
+func localReranker(reranker Reranker, lambda float64, neighborsUsers, recipes dataframe.DataFrame, recommender Recommender, profiles RecipeProfiles, weight NeighborWeight) (Reranker, error) {
+	if lambda == 1 {
+		return reranker, nil
+	}
+	if _, err := SellabilityReranker(lambda, nil); err != nil {
+		return nil, err
+	}
+
+	appeal, err := LocalAppeal(recipes.Col("id").Records(), neighborsUsers, recommender, profiles, weight)
+	if err != nil {
+		return nil, err
+	}
+	local, err := SellabilityReranker(lambda, appeal)
+	if err != nil {
+		return nil, err
 	}
 
-	//mean cosine similarity of all users
-	sellability = sellability / float64(users.Nrow())
-	return sellability
+	return AllRerankers(reranker, local), nil
 }
@@ -0,0 +1,290 @@
+package recommend
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/julienrbrt/ut_research_project/util"
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/core"
+)
+
+//testProfiles are recipes with orthogonal, mixed and empty profiles
+func testProfiles(t *testing.T) RecipeProfiles {
+	profiles, err := NewRecipeProfiles(dataframe.LoadRecords([][]string{
+		{"id", "title", "tag_a", "ingredient_b"},
+		{"1", "A", "1", "0"},
+		{"2", "B", "0", "1"},
+		{"3", "AB", "1", "1"},
+		{"4", "Nothing", "0", "0"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return profiles
+}
+
+func TestCoverage(t *testing.T) {
+	profiles := testProfiles(t)
+
+	tests := []struct {
+		recipes, others []string
+		want            float64
+	}{
+		{[]string{"1"}, []string{"1"}, 1},
+		{[]string{"1", "2"}, []string{"3"}, 1 / math.Sqrt2},
+		//the best similarity of a recipe does not carry over to the next one
+		{[]string{"1", "2"}, []string{"1"}, 0.5},
+		{[]string{"1", "2"}, []string{"2", "1"}, 1},
+		//empty and missing profiles are similar to nothing but themselves
+		{[]string{"4"}, []string{"1", "3"}, 0},
+		{[]string{"4", "5"}, []string{"4", "5"}, 1},
+		{[]string{"1"}, nil, 0},
+		{nil, []string{"1"}, 0},
+	}
+	for _, test := range tests {
+		if got := profiles.Coverage(test.recipes, test.others); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("Coverage of %v by %v is incorrect, got '%f', want '%f'", test.recipes, test.others, got, test.want)
+		}
+	}
+}
+
+func TestMeasureSellability(t *testing.T) {
+	profiles := testProfiles(t)
+	recommendations := map[string][]string{"2": {"1"}, "3": {"3"}}
+	recommender := func(userID string) ([]string, error) {
+		return recommendations[userID], nil
+	}
+	neighbors := dataframe.LoadRecords([][]string{{"id"}, {"2"}, {"3"}})
+
+	sellability, err := MeasureSellability([]string{"1", "2"}, neighbors, recommender, profiles, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (0.5 + 1/math.Sqrt2) / 2; math.Abs(sellability.Score-want) > 1e-9 {
+		t.Errorf("Sellability is incorrect, got '%f', want '%f'", sellability.Score, want)
+	}
+	if len(sellability.Neighbors) != 2 || sellability.Neighbors[0].UserID != "2" || sellability.Neighbors[0].Score != 0.5 || sellability.Neighbors[0].Weight != 1 {
+		t.Errorf("Neighbors sellability is incorrect, got '%v'", sellability.Neighbors)
+	}
+
+	//weighted mean of the neighbors scores
+	weight := func(userID string) float64 {
+		if userID == "2" {
+			return 3
+		}
+		return 1
+	}
+	sellability, err = MeasureSellability([]string{"1", "2"}, neighbors, recommender, profiles, weight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (3*0.5 + 1/math.Sqrt2) / 4; math.Abs(sellability.Score-want) > 1e-9 {
+		t.Errorf("Weighted sellability is incorrect, got '%f', want '%f'", sellability.Score, want)
+	}
+
+	sellability, err = MeasureSellability([]string{"1", "2"}, neighbors, recommender, profiles, func(string) float64 { return 0 })
+	if err != nil || sellability.Score != 0 {
+		t.Errorf("Sellability with null weights is incorrect, got '%f' and '%v', want '0'", sellability.Score, err)
+	}
+
+	sellability, err = MeasureSellability([]string{"1", "2"}, dataframe.DataFrame{}, recommender, profiles, nil)
+	if err != nil || sellability.Score != 0 || len(sellability.Neighbors) != 0 {
+		t.Errorf("Sellability without neighbors is incorrect, got '%v' and '%v', want '0'", sellability, err)
+	}
+
+	if _, err := MeasureSellability([]string{"1"}, neighbors, recommender, profiles, func(string) float64 { return -1 }); err == nil {
+		t.Error("Negative weights should fail")
+	}
+	failing := func(string) ([]string, error) { return nil, errors.New("no recommendation") }
+	if _, err := MeasureSellability([]string{"1"}, neighbors, failing, profiles, nil); err == nil {
+		t.Error("Recommender errors should fail")
+	}
+}
+
+//fitCountingModel counts the fits of a model
+type fitCountingModel struct {
+	tableModel
+	fits *int
+}
+
+func (m fitCountingModel) Fit(trainSet core.DataSetInterface, options *base.RuntimeOptions) {
+	*m.fits++
+}
+
+func TestCollaborativeRecommender(t *testing.T) {
+	fits := 0
+	m := fitCountingModel{tableModel{NewMatrixFactorization(base.Params{base.NFactors: 2}), map[string]map[string]float64{
+		"2": {"1": 1, "2": 4, "3": 5},
+		"3": {"1": 3, "2": 5, "3": 1},
+	}}, &fits}
+	data := ordersDataSet(testOrders())
+
+	recommendItems, err := CollaborativeRecommender(2, m, data, data)("3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//user 3 ordered recipe 2
+	if len(recommendItems) != 2 || recommendItems[0] != "1" || recommendItems[1] != "3" {
+		t.Errorf("Recommendations are incorrect, got '%v', want '[1 3]'", recommendItems)
+	}
+
+	neighbors := dataframe.LoadRecords([][]string{{"id"}, {"2"}, {"3"}})
+	if _, err := MeasureSellability([]string{"1"}, neighbors, CollaborativeRecommender(2, m, data, data), testProfiles(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	if fits != 0 {
+		t.Errorf("Model is fitted again for the neighbors, got '%d' fits, want '0'", fits)
+	}
+}
+
+func TestContentRecommender(t *testing.T) {
+	recommender, err := ContentRecommender(10, 3, testOrders(), testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//same recommendations as content filtering
+	recommendItems, err := recommender("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"4", "3", "1", "2"}
+	if len(recommendItems) != len(want) {
+		t.Fatalf("Recommendations are incorrect, got '%v', want '%v'", recommendItems, want)
+	}
+	for i := range want {
+		if recommendItems[i] != want[i] {
+			t.Errorf("Recommendations are incorrect, got '%v', want '%v'", recommendItems, want)
+			break
+		}
+	}
+
+	if _, err := recommender("one"); err == nil {
+		t.Error("Non integer user id should fail")
+	}
+}
+
+func TestKernelWeight(t *testing.T) {
+	tests := []struct {
+		kernel   Kernel
+		distance float64
+		want     float64
+	}{
+		{UniformKernel, 4, 1},
+		{LinearKernel, 0, 1},
+		{LinearKernel, 1, 0.75},
+		{LinearKernel, 5, 0},
+		{GaussianKernel, 0, 1},
+		{GaussianKernel, 2, math.Exp(-0.5)},
+		{StepKernel, 2, 1},
+		{StepKernel, 2.1, 0},
+	}
+	for _, test := range tests {
+		if got := test.kernel.weight(test.distance, 4); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("Weight of %s kernel at %.1f km is incorrect, got '%f', want '%f'", test.kernel, test.distance, got, test.want)
+		}
+	}
+
+	if _, err := ParseKernel("triangle"); err == nil {
+		t.Error("Unknown kernel should fail")
+	}
+}
+
+func TestDistanceWeight(t *testing.T) {
+	users := dataframe.LoadRecords([][]string{
+		{"id", "latitude", "longitude"},
+		{"1", "52.21", "6.88"},
+		{"2", "52.211", "6.881"},
+		{"3", "52.23", "6.89"},
+	})
+	far := util.DistanceTo(52.21, 6.88, 52.23, 6.89)
+
+	weight, err := DistanceWeight(1, LinearKernel, 5, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := weight("3"); math.Abs(w-(1-far/5)) > 1e-9 {
+		t.Errorf("Weight of user 3 is incorrect, got '%f', want '%f'", w, 1-far/5)
+	}
+	if weight("2") <= weight("3") {
+		t.Error("Closer neighbors should weigh more")
+	}
+	if w := weight("9"); w != 0 {
+		t.Errorf("Weight of an unknown user is incorrect, got '%f', want '0'", w)
+	}
+
+	//the closest neighbor dominates the sellability
+	profiles := testProfiles(t)
+	recommendations := map[string][]string{"2": {"1"}, "3": {"2"}}
+	recommender := func(userID string) ([]string, error) {
+		return recommendations[userID], nil
+	}
+	neighbors := dataframe.LoadRecords([][]string{{"id"}, {"2"}, {"3"}})
+	uniform, _ := MeasureSellability([]string{"1"}, neighbors, recommender, profiles, nil)
+	weighted, _ := MeasureSellability([]string{"1"}, neighbors, recommender, profiles, weight)
+	if uniform.Score != 0.5 || weighted.Score <= uniform.Score {
+		t.Errorf("Weighted sellability is incorrect, got '%f' and '%f' uniformly", weighted.Score, uniform.Score)
+	}
+
+	if weight, err := DistanceWeight(1, UniformKernel, 0, users); err != nil || weight != nil {
+		t.Errorf("Uniform weight is incorrect, got '%v'", err)
+	}
+	if _, err := DistanceWeight(1, GaussianKernel, 0, users); err == nil {
+		t.Error("Null radius should fail")
+	}
+	if _, err := DistanceWeight(9, GaussianKernel, 5, users); err == nil {
+		t.Error("User without location should fail")
+	}
+}
+
+func TestSellabilityReranker(t *testing.T) {
+	profiles := testProfiles(t)
+	recommender := func(userID string) ([]string, error) {
+		return []string{"1"}, nil
+	}
+	neighbors := dataframe.LoadRecords([][]string{{"id"}, {"2"}, {"3"}})
+
+	//the neighbors are recommended recipe 1, recipe 3 shares its tag
+	appeal, err := LocalAppeal([]string{"1", "2", "3", "4"}, neighbors, recommender, profiles, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]float64{"1": 1, "2": 0, "3": 1 / math.Sqrt2, "4": 0} {
+		if math.Abs(appeal[id]-want) > 1e-9 {
+			t.Errorf("Appeal of recipe %s is incorrect, got '%f', want '%f'", id, appeal[id], want)
+		}
+	}
+
+	ranking := func(lambda float64) []string {
+		reranker, err := SellabilityReranker(lambda, appeal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		candidates := []kv{{"2", 5}, {"3", 4.9}, {"1", 1}}
+		rerank(candidates, reranker)
+		var ids []string
+		for _, c := range candidates {
+			ids = append(ids, c.Key)
+		}
+		return ids
+	}
+	//the lower lambda, the more the appeal to the neighbors outweighs the predicted rating
+	for lambda, want := range map[float64][]string{1: {"2", "3", "1"}, 0.5: {"3", "1", "2"}, 0.1: {"1", "3", "2"}} {
+		if got := ranking(lambda); !reflect.DeepEqual(got, want) {
+			t.Errorf("Ranking with lambda %.1f is incorrect, got '%v', want '%v'", lambda, got, want)
+		}
+	}
+
+	for _, lambda := range []float64{0, 1.5} {
+		if _, err := SellabilityReranker(lambda, appeal); err == nil {
+			t.Errorf("Lambda %.1f should fail", lambda)
+		}
+	}
+	if reranker, err := SellabilityReranker(0.5, map[string]float64{"1": 0.3, "2": 0.3}); err != nil || reranker != nil {
+		t.Error("Reranker should be nil when all recipes are as appealing")
+	}
+}
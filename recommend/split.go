@@ -0,0 +1,131 @@
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/julienrbrt/ut_research_project/util"
+	"github.com/zhenghaoz/gorse/core"
+)
+
+//Splitter splits the orders dataset in a training and a test dataset
+//data must be the dataset built from orders (see ordersDataSet)
+type Splitter func(data core.DataSetInterface, orders dataframe.DataFrame) (train, test core.DataSetInterface, err error)
+
+//ordersDataSet loads the orders as a dataset, ratings are indexed in the same order than the orders rows
+//orders without rating use their confidence (see ImplicitOrders) or 1 per order
+func ordersDataSet(orders dataframe.DataFrame) *core.DataSet {
+	var ratings []float64
+	switch {
+	case hasColumn(orders, "rating"):
+		ratings = orders.Col("rating").Float()
+	case hasColumn(orders, "confidence"):
+		ratings = orders.Col("confidence").Float()
+	default:
+		ratings = make([]float64, orders.Nrow())
+		for i := range ratings {
+			ratings[i] = 1
+		}
+	}
+
+	return core.NewDataSet(orders.Col("user_id").Records(), orders.Col("recipe_id").Records(), ratings)
+}
+
+//ordersDate parses the date of each orders
+func ordersDate(orders dataframe.DataFrame) ([]time.Time, error) {
+	if !hasColumn(orders, "date") {
+		return nil, errors.New("orders have no date column, regenerate the orders to use a temporal split")
+	}
+
+	records := orders.Col("date").Records()
+	dates := make([]time.Time, len(records))
+	for i, r := range records {
+		date, err := time.Parse(util.DateLayout, r)
+		if err != nil {
+			return nil, fmt.Errorf("order %d has an invalid date: %v", i+1, err)
+		}
+		dates[i] = date
+	}
+
+	return dates, nil
+}
+
+//RandomSplit splits randomly the orders with testRatio of the orders in the test dataset
+func RandomSplit(testRatio float64) Splitter {
+	return func(data core.DataSetInterface, orders dataframe.DataFrame) (core.DataSetInterface, core.DataSetInterface, error) {
+		train, test := core.Split(data, testRatio)
+		return train, test, nil
+	}
+}
+
+//TemporalSplit trains on the orders placed before cutoff and tests on the orders placed from cutoff
+func TemporalSplit(cutoff time.Time) Splitter {
+	return func(data core.DataSetInterface, orders dataframe.DataFrame) (core.DataSetInterface, core.DataSetInterface, error) {
+		dates, err := ordersDate(orders)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var trainIndex, testIndex []int
+		for i, date := range dates {
+			if date.Before(cutoff) {
+				trainIndex = append(trainIndex, i)
+			} else {
+				testIndex = append(testIndex, i)
+			}
+		}
+
+		if len(trainIndex) == 0 || len(testIndex) == 0 {
+			return nil, nil, fmt.Errorf("cutoff %s leaves %d orders for training and %d orders for testing", cutoff.Format(util.DateLayout), len(trainIndex), len(testIndex))
+		}
+
+		return data.SubSet(trainIndex), data.SubSet(testIndex), nil
+	}
+}
+
+//LeaveLastOneOutSplit tests on the last order of each user and trains on all the previous ones
+//users with a single order are kept in the training dataset
+func LeaveLastOneOutSplit() Splitter {
+	return func(data core.DataSetInterface, orders dataframe.DataFrame) (core.DataSetInterface, core.DataSetInterface, error) {
+		dates, err := ordersDate(orders)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		//group orders by user
+		userOrders := make(map[string][]int)
+		for i, u := range orders.Col("user_id").Records() {
+			userOrders[u] = append(userOrders[u], i)
+		}
+
+		var trainIndex, testIndex []int
+		for _, indexes := range userOrders {
+			//sort user orders chronologically, same date orders keep their dataset order
+			sort.SliceStable(indexes, func(i, j int) bool {
+				return dates[indexes[i]].Before(dates[indexes[j]])
+			})
+
+			last := len(indexes) - 1
+			if last == 0 {
+				trainIndex = append(trainIndex, indexes...)
+				continue
+			}
+
+			trainIndex = append(trainIndex, indexes[:last]...)
+			testIndex = append(testIndex, indexes[last])
+		}
+
+		if len(testIndex) == 0 {
+			return nil, nil, errors.New("no user has more than one order to leave out")
+		}
+
+		//keep the dataset order
+		sort.Ints(trainIndex)
+		sort.Ints(testIndex)
+
+		return data.SubSet(trainIndex), data.SubSet(testIndex), nil
+	}
+}
@@ -0,0 +1,82 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+func testOrders() dataframe.DataFrame {
+	return dataframe.LoadRecords([][]string{
+		{"user_id", "recipe_id", "rating", "date"},
+		{"1", "1", "5", "2020-01-01 12:00:00"},
+		{"1", "2", "4", "2020-03-01 12:00:00"},
+		{"1", "3", "3", "2020-02-01 12:00:00"},
+		{"2", "1", "2", "2020-01-15 12:00:00"},
+		{"2", "3", "1", "2020-04-01 12:00:00"},
+		{"3", "2", "5", "2020-05-01 12:00:00"},
+	})
+}
+
+func TestTemporalSplit(t *testing.T) {
+	orders := testOrders()
+	data := ordersDataSet(orders)
+
+	train, test, err := TemporalSplit(time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC))(data, orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if train.Count() != 3 || test.Count() != 3 {
+		t.Errorf("Split is incorrect, got '%d' train and '%d' test orders, want '3' and '3'", train.Count(), test.Count())
+	}
+
+	for i := 0; i < test.Count(); i++ {
+		user, item, _ := test.Get(i)
+		if user == "1" && item == "1" {
+			t.Errorf("Order of user %s for recipe %s placed before the cutoff is in the test dataset", user, item)
+		}
+	}
+
+	//cutoff after all orders
+	_, _, err = TemporalSplit(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))(data, orders)
+	if err == nil {
+		t.Error("Expected an error with an empty test dataset")
+	}
+}
+
+func TestLeaveLastOneOutSplit(t *testing.T) {
+	orders := testOrders()
+	data := ordersDataSet(orders)
+
+	train, test, err := LeaveLastOneOutSplit()(data, orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"1": "2", "2": "3"}
+	if test.Count() != len(expected) {
+		t.Fatalf("Test dataset is incorrect, got '%d' orders, want '%d'", test.Count(), len(expected))
+	}
+	for i := 0; i < test.Count(); i++ {
+		user, item, _ := test.Get(i)
+		if expected[user] != item {
+			t.Errorf("Left out order of user %s is incorrect, got '%s', want '%s'", user, item, expected[user])
+		}
+	}
+
+	//user 3 has a single order kept for training
+	if train.User("3").Len() != 1 {
+		t.Errorf("User with a single order should be kept in the training dataset")
+	}
+}
+
+func TestSplitWithoutDate(t *testing.T) {
+	orders := testOrders().Drop("date")
+	data := ordersDataSet(orders)
+
+	if _, _, err := LeaveLastOneOutSplit()(data, orders); err == nil {
+		t.Error("Expected an error for orders without date")
+	}
+}
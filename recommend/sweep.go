@@ -0,0 +1,351 @@
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/go-gota/gota/series"
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/core"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+//contentFilteringName is the name of content filtering in the sweep results
+const contentFilteringName = "Content Filtering"
+
+//SweepConfig defines the radii and users of a sellability sweep
+type SweepConfig struct {
+	//Radii are the neighbors radii in km
+	Radii []float64
+	//Users is the number of users sampled, all users when 0
+	Users int
+	//Seed seeds the sample of users
+	Seed int64
+	//NbRecipes is the number of recipes recommended to each user
+	NbRecipes int
+	//NbTags is the number of preferred tags of content filtering, content filtering is not measured when 0
+	NbTags int
+	//Kernel weights the neighbors in the sellability (see DistanceWeight), the kernel radius is the neighbors radius
+	Kernel Kernel
+	//Workers is the number of users measured in parallel, the number of CPUs when 0
+	Workers int
+}
+
+//Validate verifies the sweep configuration
+func (c SweepConfig) Validate() error {
+	if len(c.Radii) == 0 {
+		return errors.New("sweep needs at least one radius")
+	}
+	for _, r := range c.Radii {
+		if r <= 0 {
+			return fmt.Errorf("radius %v must be positive", r)
+		}
+	}
+	if c.Users < 0 || c.Workers < 0 {
+		return errors.New("number of users and workers must not be negative")
+	}
+	if c.NbRecipes <= 0 {
+		return errors.New("number of recommended recipes must be positive")
+	}
+	if _, err := ParseKernel(string(c.Kernel)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//SweepRecord is the value of a metric for a user, a model and a radius
+//metrics are precision, recall, sellability and neighbors (their number)
+//precision and recall do not depend on the radius and are repeated for each radius, content filtering has none
+type SweepRecord struct {
+	UserID int
+	Model  string
+	Radius float64
+	Metric string
+	Value  float64
+}
+
+//SweepResult contains the records of a sweep, per user in the order of the sample
+type SweepResult []SweepRecord
+
+//sweepModel is a model measured by the sweep
+type sweepModel struct {
+	name        string
+	recommender Recommender
+	//evaluated is true when precision and recall are measured on the test set
+	evaluated bool
+}
+
+//cachedRecommender returns a recommender computing the recommendations of each user once, safe for concurrent use
+func cachedRecommender(recommender Recommender) Recommender {
+	var mu sync.Mutex
+	cache := make(map[string][]string)
+
+	return func(userID string) ([]string, error) {
+		mu.Lock()
+		recommendations, ok := cache[userID]
+		mu.Unlock()
+		if ok {
+			return recommendations, nil
+		}
+
+		recommendations, err := recommender(userID)
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		cache[userID] = recommendations
+		mu.Unlock()
+		return recommendations, nil
+	}
+}
+
+//Sweep measures the precision, recall and sellability of the models for a sample of users and each radius
+//the models are fitted once on the train orders of the split, the users are measured in parallel
+//and the recommendations of each user are computed once for all radii and all the users it neighbors
+//sampled users without location are skipped and reported in the log
+//content filtering uses the ratings of the orders, the models are trained and evaluated on the feedback,
+//the orders themselves or their implicit feedback (see ImplicitOrders)
+func Sweep(models []NamedModel, split Splitter, config SweepConfig, users, orders, feedback, recipes dataframe.DataFrame) (SweepResult, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	workers := config.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+
+	//orders without rating are implicit feedback
+	if isImplicit(feedback) {
+		split = ImplicitSplit(split)
+	}
+	data := ordersDataSet(feedback)
+	train, test, err := split(data, feedback)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := NewRecipeProfiles(recipes)
+	if err != nil {
+		return nil, err
+	}
+	index, err := NewUsersIndex(users)
+	if err != nil {
+		return nil, err
+	}
+
+	var measured []sweepModel
+	if config.NbTags > 0 {
+		recommender, err := ContentRecommender(config.NbRecipes, config.NbTags, orders, recipes)
+		if err != nil {
+			return nil, err
+		}
+		measured = append(measured, sweepModel{contentFilteringName, cachedRecommender(recommender), false})
+	}
+	for _, nm := range models {
+		nm.Model.Fit(train, nil)
+		measured = append(measured, sweepModel{nm.Name, cachedRecommender(CollaborativeRecommender(config.NbRecipes, nm.Model, data, train)), true})
+	}
+
+	//sample of users
+	ids, err := users.Col("id").Int()
+	if err != nil {
+		return nil, err
+	}
+	if config.Users > 0 && config.Users < len(ids) {
+		perm := rand.New(rand.NewSource(config.Seed)).Perm(len(ids))
+		sample := make([]int, config.Users)
+		for i := range sample {
+			sample[i] = ids[perm[i]]
+		}
+		ids = sample
+	}
+
+	//users without location have no neighbors to measure the sellability with, they are skipped
+	var located, skipped []int
+	for _, id := range ids {
+		if _, ok := index.location(id); ok {
+			located = append(located, id)
+		} else {
+			skipped = append(skipped, id)
+		}
+	}
+	if len(skipped) > 0 {
+		log.Printf("Skipping %d sampled users without location: %v\n", len(skipped), skipped)
+	}
+	ids = located
+
+	//measure the users in parallel, the records are kept in the order of the sample
+	records := make([][]SweepRecord, len(ids))
+	errs := make([]error, len(ids))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				records[i], errs[i] = sweepUser(ids[i], measured, config, data, test, profiles, index)
+			}
+		}()
+	}
+	for i := range ids {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var result SweepResult
+	for i := range ids {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("user %d: %v", ids[i], errs[i])
+		}
+		result = append(result, records[i]...)
+	}
+
+	return result, nil
+}
+
+//sweepUser measures the models for a user and each radius
+func sweepUser(userID int, models []sweepModel, config SweepConfig, data *core.DataSet, test core.DataSetInterface, profiles RecipeProfiles, index *UsersIndex) ([]SweepRecord, error) {
+	id := strconv.Itoa(userID)
+
+	//orders of the user in the test set
+	var testItems *base.MarginalSubSet
+	if data.UserIndexer().ToIndex(id) != base.NotId {
+		testItems = test.User(id)
+	}
+
+	var records []SweepRecord
+	for _, m := range models {
+		recommendations, err := m.recommender(id)
+		if err != nil {
+			return nil, err
+		}
+		evaluated := m.evaluated && testItems != nil && testItems.Len() > 0 && len(recommendations) > 0
+
+		for _, radius := range config.Radii {
+			neighbors := index.CloseByXKm(userID, radius)
+			weight, err := index.distanceWeight(userID, config.Kernel, radius)
+			if err != nil {
+				return nil, err
+			}
+			sellability, err := MeasureSellability(recommendations, neighbors, m.recommender, profiles, weight)
+			if err != nil {
+				return nil, err
+			}
+
+			if evaluated {
+				records = append(records,
+					SweepRecord{userID, m.name, radius, "precision", core.Precision(testItems, recommendations)},
+					SweepRecord{userID, m.name, radius, "recall", core.Recall(testItems, recommendations)})
+			}
+			records = append(records,
+				SweepRecord{userID, m.name, radius, "sellability", sellability.Score},
+				SweepRecord{userID, m.name, radius, "neighbors", float64(neighbors.Nrow())})
+		}
+	}
+
+	return records, nil
+}
+
+//DataFrame returns the records as a tidy dataframe with user, model, radius, metric and value columns
+func (r SweepResult) DataFrame() dataframe.DataFrame {
+	userIDs := make([]int, len(r))
+	models := make([]string, len(r))
+	radii := make([]float64, len(r))
+	metrics := make([]string, len(r))
+	values := make([]float64, len(r))
+	for i, record := range r {
+		userIDs[i] = record.UserID
+		models[i] = record.Model
+		radii[i] = record.Radius
+		metrics[i] = record.Metric
+		values[i] = record.Value
+	}
+
+	return dataframe.New(
+		series.New(userIDs, series.Int, "user"),
+		series.New(models, series.String, "model"),
+		series.New(radii, series.Float, "radius"),
+		series.New(metrics, series.String, "metric"),
+		series.New(values, series.Float, "value"),
+	)
+}
+
+//SweepSummary contains the mean of a metric over the users for a model and a radius, and its confidence interval
+type SweepSummary struct {
+	Model  string
+	Radius float64
+	Metric string
+	//N is the number of users measured
+	N      int
+	Mean   float64
+	StdDev float64
+	//Low and High bound the confidence interval of the mean (Student's t), equal to the mean for a single user
+	Low  float64
+	High float64
+}
+
+//Summary returns the summary of each metric per model and radius, in the order of the models and metrics of the records
+//confidence is the level of the confidence intervals, e.g. 0.95
+func (r SweepResult) Summary(confidence float64) []SweepSummary {
+	type key struct {
+		model  string
+		radius float64
+		metric string
+	}
+	values := make(map[key][]float64)
+	var keys []key
+	modelOrder := make(map[string]int)
+	metricOrder := make(map[string]int)
+	for _, record := range r {
+		k := key{record.Model, record.Radius, record.Metric}
+		if _, ok := values[k]; !ok {
+			keys = append(keys, k)
+		}
+		values[k] = append(values[k], record.Value)
+		if _, ok := modelOrder[record.Model]; !ok {
+			modelOrder[record.Model] = len(modelOrder)
+		}
+		if _, ok := metricOrder[record.Metric]; !ok {
+			metricOrder[record.Metric] = len(metricOrder)
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].model != keys[j].model {
+			return modelOrder[keys[i].model] < modelOrder[keys[j].model]
+		}
+		if keys[i].radius != keys[j].radius {
+			return keys[i].radius < keys[j].radius
+		}
+		return metricOrder[keys[i].metric] < metricOrder[keys[j].metric]
+	})
+
+	summaries := make([]SweepSummary, len(keys))
+	for i, k := range keys {
+		v := values[k]
+		s := SweepSummary{Model: k.model, Radius: k.radius, Metric: k.metric, N: len(v)}
+		if len(v) < 2 {
+			s.Mean = v[0]
+			s.Low, s.High = s.Mean, s.Mean
+		} else {
+			s.Mean, s.StdDev = stat.MeanStdDev(v, nil)
+			t := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: float64(len(v) - 1)}.Quantile(1 - (1-confidence)/2)
+			margin := t * s.StdDev / math.Sqrt(float64(len(v)))
+			s.Low, s.High = s.Mean-margin, s.Mean+margin
+		}
+		summaries[i] = s
+	}
+
+	return summaries
+}
@@ -0,0 +1,143 @@
+package recommend
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/zhenghaoz/gorse/base"
+)
+
+func TestSweep(t *testing.T) {
+	users := dataframe.LoadRecords([][]string{
+		{"id", "latitude", "longitude"},
+		{"1", "52.21", "6.88"},
+		{"2", "52.211", "6.881"},
+		{"3", "52.23", "6.89"},
+	})
+	m := tableModel{NewMatrixFactorization(base.Params{base.NFactors: 2}), map[string]map[string]float64{
+		"1": {"2": 5},
+		"2": {"3": 5, "2": 4},
+		"3": {"1": 5, "2": 4},
+	}}
+	models := []NamedModel{{Name: "Table", Model: m}}
+	split := TemporalSplit(time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC))
+	config := SweepConfig{Radii: []float64{1, 5}, NbRecipes: 1, Kernel: UniformKernel, Workers: 1}
+
+	result, err := Sweep(models, split, config, users, testOrders(), testOrders(), testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 24 {
+		t.Fatalf("Number of records is incorrect, got '%d', want '%d'", len(result), 24)
+	}
+
+	values := make(map[SweepRecord]bool)
+	for _, r := range result {
+		values[r] = true
+	}
+	//users 1 and 2 are recommended the recipe of their test order, user 3 is not
+	for _, want := range []SweepRecord{
+		{1, "Table", 1, "precision", 1},
+		{2, "Table", 5, "recall", 1},
+		{3, "Table", 1, "precision", 0},
+		{1, "Table", 1, "neighbors", 1},
+		{1, "Table", 5, "neighbors", 2},
+		{3, "Table", 1, "neighbors", 0},
+		{3, "Table", 1, "sellability", 0},
+	} {
+		if !values[want] {
+			t.Errorf("Record '%v' is missing", want)
+		}
+	}
+
+	//parallel sweeps give the same records in the same order
+	config.Workers = 4
+	parallel, err := Sweep(models, split, config, users, testOrders(), testOrders(), testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, parallel) {
+		t.Error("Parallel sweep is different from the sequential sweep")
+	}
+
+	//sample of users
+	config.Users = 2
+	sample, err := Sweep(models, split, config, users, testOrders(), testOrders(), testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sample) != 16 {
+		t.Errorf("Number of records of the sample is incorrect, got '%d', want '%d'", len(sample), 16)
+	}
+
+	//users without location are skipped, also with a distance kernel
+	config.Users = 0
+	config.Kernel = GaussianKernel
+	unlocated := users.RBind(dataframe.LoadRecords([][]string{
+		{"id", "latitude", "longitude"},
+		{"4", "", ""},
+	}))
+	located, err := Sweep(models, split, config, unlocated, testOrders(), testOrders(), testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(located) != 24 {
+		t.Errorf("Number of records without the unlocated user is incorrect, got '%d', want '%d'", len(located), 24)
+	}
+	config.Kernel = UniformKernel
+
+	//models are evaluated on the implicit feedback
+	feedback, err := ImplicitOrders(testOrders(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	implicit, err := Sweep(models, split, config, users, testOrders(), feedback, testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(implicit) != 24 {
+		t.Errorf("Number of records with implicit feedback is incorrect, got '%d', want '%d'", len(implicit), 24)
+	}
+
+	df := result.DataFrame()
+	if !reflect.DeepEqual(df.Names(), []string{"user", "model", "radius", "metric", "value"}) || df.Nrow() != 24 {
+		t.Errorf("Dataframe is incorrect, got columns '%v' and '%d' rows", df.Names(), df.Nrow())
+	}
+
+	config.Radii = nil
+	if _, err := Sweep(models, split, config, users, testOrders(), testOrders(), testRecipes()); err == nil {
+		t.Error("Sweep without radius should fail")
+	}
+}
+
+func TestSweepSummary(t *testing.T) {
+	result := SweepResult{
+		{1, "B", 1, "sellability", 1},
+		{2, "B", 1, "sellability", 2},
+		{3, "B", 1, "sellability", 3},
+		{1, "A", 1, "sellability", 0.5},
+		{1, "B", 0.5, "sellability", 0.2},
+	}
+
+	summaries := result.Summary(0.95)
+	if len(summaries) != 3 {
+		t.Fatalf("Number of summaries is incorrect, got '%d', want '%d'", len(summaries), 3)
+	}
+	//models in the order of the records, then radii
+	if summaries[0].Model != "B" || summaries[0].Radius != 0.5 || summaries[1].Radius != 1 || summaries[2].Model != "A" {
+		t.Errorf("Summaries order is incorrect, got '%v'", summaries)
+	}
+
+	s := summaries[1]
+	//t quantile of 2 degrees of freedom
+	margin := 4.302653 / math.Sqrt(3)
+	if s.N != 3 || s.Mean != 2 || math.Abs(s.StdDev-1) > 1e-9 || math.Abs(s.Low-(2-margin)) > 1e-5 || math.Abs(s.High-(2+margin)) > 1e-5 {
+		t.Errorf("Summary is incorrect, got '%v'", s)
+	}
+	if s := summaries[2]; s.N != 1 || s.Low != 0.5 || s.High != 0.5 {
+		t.Errorf("Summary of a single user is incorrect, got '%v'", s)
+	}
+}
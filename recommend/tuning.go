@@ -0,0 +1,346 @@
+package recommend
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/zhenghaoz/gorse/base"
+	"github.com/zhenghaoz/gorse/core"
+	"gonum.org/v1/gonum/stat"
+)
+
+//Trial is an evaluated candidate of a hyperparameters search
+type Trial struct {
+	//Setting identifies the evaluation of the trial (see searchSetting)
+	Setting string                 `json:"setting"`
+	Model   string                 `json:"model"`
+	Params  map[string]interface{} `json:"params"`
+	Scores  map[string]float64     `json:"scores"`
+}
+
+//TrialLog keeps the evaluated trials, trials are appended to a JSON lines file to resume an interrupted search
+type TrialLog struct {
+	file   *os.File
+	trials map[string]Trial
+}
+
+//trialKey identifies a trial by its evaluation setting, its model and all its parameters
+//trials logged with another cross validation or other orders are never reused
+func trialKey(setting, model string, params map[string]interface{}) string {
+	//maps are encoded with sorted keys
+	content, _ := json.Marshal(params)
+	return setting + " " + model + " " + string(content)
+}
+
+//searchSetting returns the setting of the trials evaluated with the search configuration on the orders:
+//the cross validation, number of folds, seed, number of recommended recipes and a fingerprint of the orders
+func searchSetting(config SearchConfig, orders dataframe.DataFrame) string {
+	hash := sha256.New()
+	for _, record := range orders.Records() {
+		hash.Write([]byte(strings.Join(record, "\x1f") + "\n"))
+	}
+
+	return fmt.Sprintf("cv=%s folds=%d seed=%d nbRecipes=%d orders=%x", config.CV, config.Folds, config.Seed, config.NbRecipes, hash.Sum(nil)[:8])
+}
+
+//OpenTrialLog opens a trial log and loads the trials already evaluated
+//the trials are only kept in memory when path is empty
+func OpenTrialLog(path string) (*TrialLog, error) {
+	trials := &TrialLog{trials: make(map[string]Trial)}
+	if path == "" {
+		return trials, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var t Trial
+		if err := json.Unmarshal(scanner.Bytes(), &t); err != nil {
+			//an interrupted search can leave an incomplete last line
+			log.Printf("Skipping invalid trial in %s: %v\n", path, err)
+			continue
+		}
+		trials.trials[trialKey(t.Setting, t.Model, t.Params)] = t
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if len(trials.trials) > 0 {
+		log.Printf("Resuming search from %d trials logged in %s\n", len(trials.trials), path)
+	}
+	trials.file = f
+
+	return trials, nil
+}
+
+//lookup returns the logged trial of a model with the same parameters evaluated in the same setting
+func (l *TrialLog) lookup(setting, model string, params map[string]interface{}) (Trial, bool) {
+	t, ok := l.trials[trialKey(setting, model, params)]
+	return t, ok
+}
+
+//append logs an evaluated trial
+func (l *TrialLog) append(t Trial) error {
+	l.trials[trialKey(t.Setting, t.Model, t.Params)] = t
+	if l.file == nil {
+		return nil
+	}
+
+	content, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	_, err = l.file.Write(append(content, '\n'))
+	return err
+}
+
+//Close closes the trial log file
+func (l *TrialLog) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+//metricIndex returns the index of a metric in the search results, -1 when unknown
+func metricIndex(metric string) int {
+	for i, m := range searchMetrics {
+		if m == metric {
+			return i
+		}
+	}
+	return -1
+}
+
+//better returns if score a of metric is better than score b
+func better(metric string, a, b float64) bool {
+	if math.IsNaN(b) {
+		return !math.IsNaN(a)
+	}
+	if metric == "rmse" {
+		return a < b
+	}
+	return a > b
+}
+
+//tuner evaluates the candidates of a model hyperparameters search
+type tuner struct {
+	space     SearchSpace
+	config    SearchConfig
+	estimator core.ModelInterface
+	fixed     base.Params
+	data      core.DataSetInterface
+	splitter  core.Splitter
+	trials    *TrialLog
+	setting   string
+}
+
+//evaluate cross validates a candidate, candidates already logged are not evaluated again
+func (t *tuner) evaluate(candidate base.Params) (Trial, error) {
+	params := t.fixed.Merge(candidate)
+	trial := Trial{Setting: t.setting, Model: t.space.Name, Params: make(map[string]interface{}, len(params))}
+	for name, value := range params {
+		trial.Params[string(name)] = value
+	}
+
+	if logged, ok := t.trials.lookup(trial.Setting, trial.Model, trial.Params); ok {
+		return logged, nil
+	}
+
+	//cross validate
+	t.estimator.SetParams(params)
+	options := &base.RuntimeOptions{Verbose: false, FitJobs: 1, CVJobs: runtime.NumCPU()}
+	cv := core.CrossValidate(t.estimator, t.data, t.splitter, t.config.Seed, options,
+		core.NewRankEvaluator(t.config.NbRecipes, core.Precision, core.Recall), core.NewRatingEvaluator(core.RMSE))
+
+	trial.Scores = make(map[string]float64, len(searchMetrics))
+	for i, metric := range searchMetrics {
+		trial.Scores[metric] = stat.Mean(cv[i].TestScore, nil)
+	}
+	log.Printf("%s %v: %v\n", t.space.Name, candidate, trial.Scores)
+
+	return trial, t.trials.append(trial)
+}
+
+//paramNames returns the searched parameters in a deterministic order
+func paramNames(grid core.ParameterGrid) []base.ParamName {
+	names := make([]base.ParamName, 0, len(grid))
+	for name := range grid {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
+
+//gridSearch evaluates all the candidates of the grid
+func (t *tuner) gridSearch(grid core.ParameterGrid) ([]Trial, error) {
+	names := paramNames(grid)
+	count := 1
+	for _, name := range names {
+		count *= len(grid[name])
+	}
+
+	var trials []Trial
+	for i := 0; i < count; i++ {
+		//decode the i-th combination
+		candidate := base.Params{}
+		rest := i
+		for _, name := range names {
+			values := grid[name]
+			candidate[name] = values[rest%len(values)]
+			rest /= len(values)
+		}
+
+		log.Printf("Grid search %d / %d\n", i+1, count)
+		trial, err := t.evaluate(candidate)
+		if err != nil {
+			return nil, err
+		}
+		trials = append(trials, trial)
+	}
+
+	return trials, nil
+}
+
+//randomCandidate samples a candidate of the grid
+func randomCandidate(rng *rand.Rand, grid core.ParameterGrid, names []base.ParamName) base.Params {
+	candidate := base.Params{}
+	for _, name := range names {
+		values := grid[name]
+		candidate[name] = values[rng.Intn(len(values))]
+	}
+	return candidate
+}
+
+//randomSearch evaluates random candidates of the grid
+//the search stops early when the metric has not improved for Patience trials
+func (t *tuner) randomSearch(grid core.ParameterGrid) ([]Trial, error) {
+	if t.config.Trials <= 0 {
+		return nil, fmt.Errorf("random search needs a positive number of trials, got %d", t.config.Trials)
+	}
+
+	//same seed gives the same candidates so a logged search is resumed
+	rng := rand.New(rand.NewSource(t.config.Seed))
+	names := paramNames(grid)
+
+	var trials []Trial
+	best := math.NaN()
+	sinceBest := 0
+	for i := 0; i < t.config.Trials; i++ {
+		log.Printf("Random search %d / %d\n", i+1, t.config.Trials)
+		trial, err := t.evaluate(randomCandidate(rng, grid, names))
+		if err != nil {
+			return nil, err
+		}
+		trials = append(trials, trial)
+
+		//early stopping
+		if better(t.config.Metric, trial.Scores[t.config.Metric], best) {
+			best = trial.Scores[t.config.Metric]
+			sinceBest = 0
+		} else {
+			sinceBest++
+		}
+		if t.config.Patience > 0 && sinceBest >= t.config.Patience {
+			log.Printf("Stopping random search, %s has not improved for %d trials\n", t.config.Metric, sinceBest)
+			break
+		}
+	}
+
+	return trials, nil
+}
+
+//successiveHalving evaluates random candidates with a growing number of epochs
+//each round keeps the 1/eta best candidates on the metric and multiplies their epochs by eta
+//the trials of the last round are returned
+func (t *tuner) successiveHalving(grid core.ParameterGrid) ([]Trial, error) {
+	config := t.config
+	if config.Trials <= 0 || config.Eta < 2 || config.MinEpochs <= 0 || config.MaxEpochs < config.MinEpochs {
+		return nil, fmt.Errorf("successive halving needs positive trials, eta >= 2 and 0 < minEpochs <= maxEpochs, got %d, %d, %d and %d",
+			config.Trials, config.Eta, config.MinEpochs, config.MaxEpochs)
+	}
+
+	//the number of epochs is the budget of the rounds
+	names := paramNames(grid)
+	for i, name := range names {
+		if name == base.NEpochs {
+			names = append(names[:i], names[i+1:]...)
+			break
+		}
+	}
+
+	//distinct random candidates
+	rng := rand.New(rand.NewSource(config.Seed))
+	size := 1
+	for _, name := range names {
+		size *= len(grid[name])
+	}
+	var candidates []base.Params
+	seen := make(map[string]bool)
+	for attempts := 0; len(candidates) < config.Trials && len(candidates) < size && attempts < 100*config.Trials; attempts++ {
+		candidate := randomCandidate(rng, grid, names)
+		content, _ := json.Marshal(candidate)
+		if seen[string(content)] {
+			continue
+		}
+		seen[string(content)] = true
+		candidates = append(candidates, candidate)
+	}
+
+	budget := config.MinEpochs
+	for round := 1; ; round++ {
+		log.Printf("Successive halving round %d: %d candidates with %d epochs\n", round, len(candidates), budget)
+
+		trials := make([]Trial, len(candidates))
+		for i, candidate := range candidates {
+			candidate[base.NEpochs] = budget
+			trial, err := t.evaluate(candidate)
+			if err != nil {
+				return nil, err
+			}
+			trials[i] = trial
+		}
+
+		if budget >= config.MaxEpochs || len(candidates) == 1 {
+			return trials, nil
+		}
+
+		//keep the best candidates
+		order := make([]int, len(trials))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return better(config.Metric, trials[order[i]].Scores[config.Metric], trials[order[j]].Scores[config.Metric])
+		})
+		keep := int(math.Ceil(float64(len(candidates)) / float64(config.Eta)))
+		kept := make([]base.Params, keep)
+		for i := range kept {
+			kept[i] = candidates[order[i]]
+		}
+		candidates = kept
+
+		budget *= config.Eta
+		if budget > config.MaxEpochs {
+			budget = config.MaxEpochs
+		}
+	}
+}
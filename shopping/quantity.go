@@ -0,0 +1,134 @@
+package shopping
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//unit is a unit of an ingredient quantity and its conversion to a base unit
+type unit struct {
+	base   string
+	factor float64
+}
+
+//units contains the Dutch units of the recipes, weights are converted to g and volumes to ml
+//counted units (blik, teen...) are singularised, a quantity without unit is a number of pieces
+var units = map[string]unit{
+	"mg": {"g", 0.001}, "g": {"g", 1}, "gr": {"g", 1}, "gram": {"g", 1}, "kg": {"g", 1000}, "kilo": {"g", 1000},
+	"ml": {"ml", 1}, "cl": {"ml", 10}, "dl": {"ml", 100}, "l": {"ml", 1000}, "liter": {"ml", 1000},
+	"tl": {"ml", 5}, "theelepel": {"ml", 5}, "theelepels": {"ml", 5},
+	"el": {"ml", 15}, "eetlepel": {"ml", 15}, "eetlepels": {"ml", 15},
+	"stuk": {"", 1}, "stuks": {"", 1}, "st": {"", 1},
+	"blik": {"blik", 1}, "blikken": {"blik", 1},
+	"pak": {"pak", 1}, "pakken": {"pak", 1},
+	"zak": {"zak", 1}, "zakken": {"zak", 1}, "zakje": {"zakje", 1}, "zakjes": {"zakje", 1},
+	"bos": {"bos", 1}, "bosje": {"bos", 1}, "bosjes": {"bos", 1},
+	"teen": {"teen", 1}, "tenen": {"teen", 1}, "teentje": {"teen", 1}, "teentjes": {"teen", 1},
+	"plak": {"plak", 1}, "plakken": {"plak", 1}, "plakjes": {"plak", 1},
+	"takje": {"takje", 1}, "takjes": {"takje", 1},
+	"snuf": {"snuf", 1}, "snufje": {"snuf", 1},
+	"pot": {"pot", 1}, "potje": {"pot", 1}, "potten": {"pot", 1},
+	"bak": {"bak", 1}, "bakje": {"bak", 1}, "bakjes": {"bak", 1},
+}
+
+//fractions written as a single character
+var fractions = strings.NewReplacer("½", " 1/2", "¼", " 1/4", "¾", " 3/4", "⅓", " 1/3", "⅔", " 2/3")
+
+var (
+	numberRegexp   = regexp.MustCompile(`^\d+([.,]\d+)?$`)
+	fractionRegexp = regexp.MustCompile(`^(\d+)/(\d+)$`)
+	rangeRegexp    = regexp.MustCompile(`^\d+([.,]\d+)?-(\d+([.,]\d+)?)$`)
+	attachedRegexp = regexp.MustCompile(`^(\d+([.,]\d+)?)([a-z]+)$`)
+)
+
+//number parses a number written with a decimal point or comma, a fraction or a range (its upper bound, to buy enough)
+func number(token string) (float64, bool) {
+	switch {
+	case numberRegexp.MatchString(token):
+		v, err := strconv.ParseFloat(strings.Replace(token, ",", ".", 1), 64)
+		return v, err == nil
+	case fractionRegexp.MatchString(token):
+		m := fractionRegexp.FindStringSubmatch(token)
+		n, _ := strconv.ParseFloat(m[1], 64)
+		d, _ := strconv.ParseFloat(m[2], 64)
+		if d == 0 {
+			return 0, false
+		}
+		return n / d, true
+	case rangeRegexp.MatchString(token):
+		return number(rangeRegexp.FindStringSubmatch(token)[2])
+	}
+
+	return 0, false
+}
+
+//Quantity is a parsed quantity of an ingredient in its base unit
+type Quantity struct {
+	Amount float64
+	//Unit is g, ml, a counted unit (blik, teen...) or empty for pieces
+	Unit string
+	//Known is false when the line has no amount, like salt to taste
+	Known bool
+}
+
+//ParseLine parses an ingredient line like "1½ el olijfolie" or "400g" as a quantity and the rest of the line
+func ParseLine(line string) (Quantity, string) {
+	tokens := strings.Fields(strings.ToLower(fractions.Replace(line)))
+
+	//amount, possibly a whole number followed by a fraction
+	var q Quantity
+	i := 0
+	for ; i < len(tokens); i++ {
+		if m := attachedRegexp.FindStringSubmatch(tokens[i]); m != nil {
+			if _, ok := units[m[3]]; ok {
+				//unit attached to the amount, like 400g
+				v, _ := number(m[1])
+				q.Amount += v
+				q.Known = true
+				tokens[i] = m[3]
+				break
+			}
+		}
+		v, ok := number(tokens[i])
+		if !ok {
+			break
+		}
+		q.Amount += v
+		q.Known = true
+	}
+
+	//unit
+	factor := 1.0
+	if i < len(tokens) {
+		if u, ok := units[strings.TrimSuffix(tokens[i], ".")]; ok && q.Known {
+			q.Unit, factor = u.base, u.factor
+			i++
+		}
+	}
+	q.Amount *= factor
+
+	return q, strings.Join(tokens[i:], " ")
+}
+
+//String formats a quantity, large weights and volumes in kg and l
+func (q Quantity) String() string {
+	if !q.Known {
+		return ""
+	}
+
+	amount, u := q.Amount, q.Unit
+	switch {
+	case u == "g" && amount >= 1000:
+		amount, u = amount/1000, "kg"
+	case u == "ml" && amount >= 1000:
+		amount, u = amount/1000, "l"
+	}
+
+	s := strconv.FormatFloat(amount, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if u == "" {
+		return s
+	}
+	return s + " " + u
+}
@@ -0,0 +1,54 @@
+package shopping
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		amount float64
+		unit   string
+		known  bool
+		rest   string
+	}{
+		{"400 g spaghetti", 400, "g", true, "spaghetti"},
+		{"400g", 400, "g", true, ""},
+		{"1,5 kg", 1500, "g", true, ""},
+		{"1½ el olijfolie", 22.5, "ml", true, "olijfolie"},
+		{"1 1/2 tl", 7.5, "ml", true, ""},
+		{"2-3 tenen knoflook", 3, "teen", true, "knoflook"},
+		{"2 blikken", 2, "blik", true, ""},
+		{"1 ui", 1, "", true, "ui"},
+		{"0,5 l", 500, "ml", true, ""},
+		{"snufje", 0, "", false, "snufje"},
+		{"peper en zout", 0, "", false, "peper en zout"},
+	}
+
+	for _, test := range tests {
+		q, rest := ParseLine(test.line)
+		if math.Abs(q.Amount-test.amount) > 1e-9 || q.Unit != test.unit || q.Known != test.known || rest != test.rest {
+			t.Errorf("Parsed line %q is incorrect, got '%v' '%s', want '%v' '%s'", test.line, q, rest, Quantity{test.amount, test.unit, test.known}, test.rest)
+		}
+	}
+}
+
+func TestQuantityString(t *testing.T) {
+	tests := []struct {
+		quantity Quantity
+		want     string
+	}{
+		{Quantity{1500, "g", true}, "1.5 kg"},
+		{Quantity{250, "ml", true}, "250 ml"},
+		{Quantity{2, "", true}, "2"},
+		{Quantity{0.333333, "blik", true}, "0.33 blik"},
+		{Quantity{}, ""},
+	}
+
+	for _, test := range tests {
+		if got := test.quantity.String(); got != test.want {
+			t.Errorf("Quantity is incorrect, got '%s', want '%s'", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package shopping
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-gota/gota/dataframe"
+	"github.com/julienrbrt/ut_research_project/util"
+)
+
+//DefaultServings is the number of servings of most AH recipes, assumed for the recipes whose servings were not scraped
+const DefaultServings = 4
+
+//other is the category of the ingredients matching no category
+const other = "other"
+
+//Categories contains the keywords of the ingredients of each supermarket category (see util.ContainsKeyword)
+type Categories map[string][]string
+
+//DefaultCategories returns the categories of the Dutch ingredients of the AH recipes
+func DefaultCategories() Categories {
+	return Categories{
+		"produce":         {"ui", "uien", "knoflook", "tomaat", "tomaten", "paprika", "wortel", "wortelen", "winterpeen", "prei", "courgette", "aubergine", "spinazie", "sla", "rucola", "komkommer", "sperziebonen", "broccoli", "bloemkool", "kool", "champignons", "paddenstoelen", "avocado", "citroen", "limoen", "appel", "peer", "banaan", "gember", "peterselie", "basilicum", "koriander", "bieslook", "munt", "aardappel", "aardappelen", "krieltjes", "groente", "groentemix", "roerbakgroente", "lente-ui", "bosui", "sjalot", "venkel", "biet", "pompoen", "mais"},
+		"meat and fish":   {"vlees", "kip", "kipfilet", "kippendij", "gehakt", "rundergehakt", "biefstuk", "varkenshaas", "spek", "spekjes", "ham", "worst", "chorizo", "kalkoen", "zalm", "kabeljauw", "tonijn", "vis", "garnalen", "mosselen", "makreel"},
+		"dairy and eggs":  {"melk", "kaas", "boter", "room", "slagroom", "kookroom", "yoghurt", "kwark", "mozzarella", "parmezaanse", "feta", "mascarpone", "ricotta", "crème", "ei", "eieren"},
+		"bakery":          {"brood", "stokbrood", "ciabatta", "naan", "pita", "tortilla", "wraps", "bladerdeeg", "pizzadeeg"},
+		"pasta and rice":  {"pasta", "spaghetti", "penne", "macaroni", "lasagne", "tagliatelle", "rijst", "noedels", "mie", "couscous", "bulgur", "quinoa"},
+		"canned and dry":  {"bonen", "kikkererwten", "linzen", "tomatenpuree", "passata", "bouillon", "bouillonblokje", "kokosmelk", "noten", "pijnboompitten", "bloem", "suiker"},
+		"herbs and spice": {"zout", "peper", "kruidenmix", "paprikapoeder", "komijn", "kerrie", "oregano", "tijm", "rozemarijn", "kaneel", "nootmuskaat"},
+		"oil and sauces":  {"olie", "olijfolie", "azijn", "saus", "sojasaus", "ketjap", "mosterd", "mayonaise", "pesto", "ketchup", "honing"},
+	}
+}
+
+//category returns the category of an ingredient, the category of the longest matching keyword when several match
+//so kokosmelk is canned and not dairy
+func (c Categories) category(ingredient string) string {
+	category, length := other, 0
+	for n, keywords := range c {
+		for _, k := range keywords {
+			l := len([]rune(k))
+			if (l > length || (l == length && n < category)) && util.ContainsKeyword(ingredient, []string{k}) {
+				category, length = n, l
+			}
+		}
+	}
+	return category
+}
+
+//Selection is a recipe to cook for a number of servings
+type Selection struct {
+	RecipeID string
+	Servings int
+}
+
+//Item is an ingredient to buy
+type Item struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount,omitempty"`
+	//Unit is g, ml, a counted unit (blik, teen...) or empty for pieces
+	Unit string `json:"unit,omitempty"`
+	//ToTaste is true when the recipes give no amount, like salt and pepper
+	ToTaste  bool     `json:"toTaste,omitempty"`
+	Category string   `json:"category"`
+	Recipes  []string `json:"recipes"`
+}
+
+//List is a shopping list, its items are sorted by category and name
+type List struct {
+	Items []Item `json:"items"`
+	//AssumedServings contains the recipes whose servings are unknown, assumed to be DefaultServings
+	AssumedServings []string `json:"assumedServings,omitempty"`
+}
+
+//line is an ingredient line of a recipe
+type line struct {
+	text       string
+	ingredient string
+}
+
+//Book contains the ingredient lines of the recipes
+type Book struct {
+	lines    map[string][]line
+	servings map[string]int
+}
+
+//NewBook creates a book from the ingredients dataframe saved by recipe.RecipesData (recipe_id, line and ingredient columns)
+//recipes have DefaultServings servings unless the dataframe has a servings column
+func NewBook(ingredients dataframe.DataFrame) (*Book, error) {
+	columns := make(map[string]bool)
+	for _, n := range ingredients.Names() {
+		columns[n] = true
+	}
+	if !columns["recipe_id"] || !columns["line"] || !columns["ingredient"] {
+		return nil, errors.New("ingredients need recipe_id, line and ingredient columns")
+	}
+
+	b := &Book{lines: make(map[string][]line), servings: make(map[string]int)}
+	ids := ingredients.Col("recipe_id").Records()
+	texts := ingredients.Col("line").Records()
+	names := ingredients.Col("ingredient").Records()
+	var servings []string
+	if columns["servings"] {
+		servings = ingredients.Col("servings").Records()
+	}
+	for i, id := range ids {
+		b.lines[id] = append(b.lines[id], line{text: texts[i], ingredient: names[i]})
+		if servings != nil {
+			if s, err := strconv.Atoi(servings[i]); err == nil && s > 0 {
+				b.servings[id] = s
+			}
+		}
+	}
+
+	return b, nil
+}
+
+//Servings returns the number of servings of a recipe, DefaultServings when unknown
+func (b *Book) Servings(recipeID string) int {
+	if s, ok := b.servings[recipeID]; ok {
+		return s
+	}
+	return DefaultServings
+}
+
+//List merges the ingredients of the selected recipes, scaled to their servings, in a shopping list
+//quantities of the same ingredient are added when their units can be converted (g, ml or the same counted unit)
+func (b *Book) List(selections []Selection, categories Categories) (*List, error) {
+	type key struct {
+		name string
+		unit string
+	}
+	items := make(map[key]*Item)
+	var order []key
+	var assumed []string
+
+	for _, s := range selections {
+		lines, ok := b.lines[s.RecipeID]
+		if !ok {
+			return nil, fmt.Errorf("recipe %s has no ingredients", s.RecipeID)
+		}
+		if s.Servings <= 0 {
+			return nil, fmt.Errorf("servings of recipe %s must be positive", s.RecipeID)
+		}
+		scale := float64(s.Servings) / float64(b.Servings(s.RecipeID))
+		if _, ok := b.servings[s.RecipeID]; !ok && !contains(assumed, s.RecipeID) {
+			assumed = append(assumed, s.RecipeID)
+		}
+
+		for _, l := range lines {
+			q, rest := ParseLine(l.text)
+			name := strings.ToLower(strings.TrimSpace(l.ingredient))
+			if name == "" {
+				name = rest
+			}
+			if name == "" {
+				continue
+			}
+
+			k := key{name, q.Unit}
+			item, ok := items[k]
+			if !ok {
+				item = &Item{Name: name, Unit: q.Unit, Category: categories.category(name)}
+				items[k] = item
+				order = append(order, k)
+			}
+			if q.Known {
+				item.Amount += q.Amount * scale
+			}
+			if !contains(item.Recipes, s.RecipeID) {
+				item.Recipes = append(item.Recipes, s.RecipeID)
+			}
+		}
+	}
+
+	list := &List{AssumedServings: assumed}
+	for _, k := range order {
+		item := items[k]
+		item.ToTaste = item.Amount == 0
+		list.Items = append(list.Items, *item)
+	}
+	sort.SliceStable(list.Items, func(i, j int) bool {
+		if list.Items[i].Category != list.Items[j].Category {
+			//other is last
+			if list.Items[i].Category == other || list.Items[j].Category == other {
+				return list.Items[j].Category == other
+			}
+			return list.Items[i].Category < list.Items[j].Category
+		}
+		return list.Items[i].Name < list.Items[j].Name
+	})
+
+	return list, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+//WriteText writes the shopping list as text, an ingredient per line under its category, followed by the recipes of unknown servings
+func (l *List) WriteText(w io.Writer) error {
+	category := ""
+	for _, item := range l.Items {
+		if item.Category != category {
+			if category != "" {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			category = item.Category
+			if _, err := fmt.Fprintf(w, "%s\n", strings.ToUpper(category)); err != nil {
+				return err
+			}
+		}
+
+		quantity := Quantity{Amount: item.Amount, Unit: item.Unit, Known: true}.String()
+		if item.ToTaste {
+			quantity = "to taste"
+		}
+		if _, err := fmt.Fprintf(w, "- %s: %s\n", item.Name, quantity); err != nil {
+			return err
+		}
+	}
+
+	if len(l.AssumedServings) > 0 {
+		if _, err := fmt.Fprintf(w, "\nServings of recipes %s are unknown, %d assumed\n", strings.Join(l.AssumedServings, ", "), DefaultServings); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//WriteJSON writes the shopping list as JSON, amounts are in g, ml, counted units or pieces
+func (l *List) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(l)
+}
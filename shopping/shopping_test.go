@@ -0,0 +1,140 @@
+package shopping
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/go-gota/gota/dataframe"
+)
+
+func testBook(t *testing.T) *Book {
+	ingredients := dataframe.LoadRecords([][]string{
+		{"recipe_id", "line", "ingredient"},
+		{"1", "400 g", "spaghetti"},
+		{"1", "2 el", "olijfolie"},
+		{"1", "1", "ui"},
+		{"1", "2 tenen", "knoflook"},
+		{"1", "", "zout"},
+		{"2", "1 blik", "kokosmelk"},
+		{"2", "1 tl", "olijfolie"},
+		{"2", "2", "ui"},
+		{"2", "10 g", "knoflook"},
+		{"2", "", "zout"},
+	}, dataframe.DetectTypes(false))
+
+	book, err := NewBook(ingredients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return book
+}
+
+func TestList(t *testing.T) {
+	book := testBook(t)
+
+	//recipe 1 for 2 people and recipe 2 for 4 people
+	list, err := book.List([]Selection{{"1", 2}, {"2", 4}}, DefaultCategories())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type want struct {
+		amount   float64
+		toTaste  bool
+		category string
+		recipes  int
+	}
+	expected := map[string]want{
+		"spaghetti g":    {200, false, "pasta and rice", 1},
+		"olijfolie ml":   {20, false, "oil and sauces", 2},
+		"ui ":            {2.5, false, "produce", 2},
+		"knoflook teen":  {1, false, "produce", 1},
+		"knoflook g":     {10, false, "produce", 1},
+		"zout ":          {0, true, "herbs and spice", 2},
+		"kokosmelk blik": {1, false, "canned and dry", 1},
+	}
+	if len(list.Items) != len(expected) {
+		t.Fatalf("Shopping list is incorrect, got '%v'", list.Items)
+	}
+	for _, item := range list.Items {
+		w, ok := expected[item.Name+" "+item.Unit]
+		if !ok {
+			t.Errorf("Unexpected item '%v'", item)
+			continue
+		}
+		if math.Abs(item.Amount-w.amount) > 1e-9 || item.ToTaste != w.toTaste || item.Category != w.category || len(item.Recipes) != w.recipes {
+			t.Errorf("Item %s is incorrect, got '%v', want '%v'", item.Name, item, w)
+		}
+	}
+
+	//items are grouped by category
+	for i := 1; i < len(list.Items); i++ {
+		if list.Items[i].Category < list.Items[i-1].Category {
+			t.Errorf("Items are not sorted by category, got '%s' after '%s'", list.Items[i].Category, list.Items[i-1].Category)
+		}
+	}
+
+	if len(list.AssumedServings) != 2 {
+		t.Errorf("Recipes of assumed servings are incorrect, got '%v', want '[1 2]'", list.AssumedServings)
+	}
+
+	if _, err := book.List([]Selection{{"3", 4}}, DefaultCategories()); err == nil {
+		t.Error("Expected an error for a recipe without ingredients")
+	}
+}
+
+func TestListExport(t *testing.T) {
+	list, err := testBook(t).List([]Selection{{"1", 4}}, DefaultCategories())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text bytes.Buffer
+	if err := list.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	want := "HERBS AND SPICE\n- zout: to taste\n\nOIL AND SAUCES\n- olijfolie: 30 ml\n\nPASTA AND RICE\n- spaghetti: 400 g\n\nPRODUCE\n- knoflook: 2 teen\n- ui: 1\n\nServings of recipes 1 are unknown, 4 assumed\n"
+	if text.String() != want {
+		t.Errorf("Text shopping list is incorrect, got '%s', want '%s'", text.String(), want)
+	}
+
+	var buffer bytes.Buffer
+	if err := list.WriteJSON(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	var decoded List
+	if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Items) != len(list.Items) || decoded.Items[0].Name != "zout" || !decoded.Items[0].ToTaste {
+		t.Errorf("JSON shopping list is incorrect, got '%s'", buffer.String())
+	}
+}
+
+func TestListServings(t *testing.T) {
+	ingredients := dataframe.LoadRecords([][]string{
+		{"recipe_id", "line", "ingredient", "servings"},
+		{"1", "400 g", "spaghetti", "2"},
+		{"2", "1 blik", "kokosmelk", ""},
+	}, dataframe.DetectTypes(false))
+	book, err := NewBook(ingredients)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//recipe 1 serves 2, recipe 2 is assumed to serve 4
+	list, err := book.List([]Selection{{"1", 4}, {"2", 2}}, DefaultCategories())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range list.Items {
+		if (item.Name == "spaghetti" && item.Amount != 800) || (item.Name == "kokosmelk" && item.Amount != 0.5) {
+			t.Errorf("Item %s is incorrect, got '%v'", item.Name, item)
+		}
+	}
+	if len(list.AssumedServings) != 1 || list.AssumedServings[0] != "2" {
+		t.Errorf("Recipes of assumed servings are incorrect, got '%v', want '[2]'", list.AssumedServings)
+	}
+}
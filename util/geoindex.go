@@ -0,0 +1,164 @@
+package util
+
+import (
+	"math"
+	"sort"
+
+	"github.com/julienrbrt/ut_research_project/geo"
+)
+
+//GeoIndex is a spatial index of locations on a grid of fixed size cells
+//radius queries only visit the cells intersecting the bounding coordinates and then filter on the exact distance
+type GeoIndex struct {
+	cellSize  float64
+	ids       []int
+	locations []Geolocation
+	cells     map[cell][]int
+}
+
+//Neighbor is a location of the index matching a query
+type Neighbor struct {
+	ID       int
+	Distance float64
+}
+
+type cell struct {
+	lat, lon int
+}
+
+//degrees of latitude in one km
+const kmToDegree = 180 / (math.Pi * geo.EarthRadius)
+
+//NewGeoIndex creates an empty index with cells of cellKm km of latitude
+//a cell size close to the usual query radius gives the best performances
+func NewGeoIndex(cellKm float64) *GeoIndex {
+	if cellKm <= 0 {
+		cellKm = 1
+	}
+
+	return &GeoIndex{
+		cellSize: cellKm * kmToDegree,
+		cells:    make(map[cell][]int),
+	}
+}
+
+//Len returns the number of locations in the index
+func (g *GeoIndex) Len() int {
+	return len(g.ids)
+}
+
+//Add adds a location to the index
+func (g *GeoIndex) Add(id int, latitude, longitude float64) {
+	c := g.cellOf(latitude, longitude)
+	g.cells[c] = append(g.cells[c], len(g.ids))
+	g.ids = append(g.ids, id)
+	g.locations = append(g.locations, Geolocation{Latitude: latitude, Longitude: longitude})
+}
+
+func (g *GeoIndex) cellOf(latitude, longitude float64) cell {
+	return cell{
+		lat: int(math.Floor(latitude / g.cellSize)),
+		lon: int(math.Floor(longitude / g.cellSize)),
+	}
+}
+
+//Within returns the locations at most km away from a location, sorted by distance
+func (g *GeoIndex) Within(latitude, longitude, km float64) []Neighbor {
+	center := Geolocation{Latitude: latitude, Longitude: longitude}
+
+	var neighbors []Neighbor
+	g.visit(latitude, longitude, km, func(i int) {
+		d := geo.Haversine(center, g.locations[i])
+		if d <= km {
+			neighbors = append(neighbors, Neighbor{ID: g.ids[i], Distance: d})
+		}
+	})
+
+	sortNeighbors(neighbors)
+
+	return neighbors
+}
+
+//Nearest returns the k closest locations to a location, sorted by distance
+func (g *GeoIndex) Nearest(latitude, longitude float64, k int) []Neighbor {
+	if k <= 0 || len(g.ids) == 0 {
+		return nil
+	}
+	if k > len(g.ids) {
+		k = len(g.ids)
+	}
+
+	//grow the search radius until it contains k locations
+	//all locations within the radius are known, so the k closest of them are the k closest of the index
+	maxRadius := math.Pi * geo.EarthRadius
+	radius := g.cellSize / kmToDegree
+	for {
+		neighbors := g.Within(latitude, longitude, radius)
+		if len(neighbors) >= k || radius >= maxRadius {
+			if len(neighbors) > k {
+				neighbors = neighbors[:k]
+			}
+			return neighbors
+		}
+		radius = math.Min(2*radius, maxRadius)
+	}
+}
+
+//visit calls fn with the locations of the cells intersecting the bounding coordinates of the radius
+func (g *GeoIndex) visit(latitude, longitude, km float64, fn func(i int)) {
+	bounds := BoundingCoordinates(latitude, longitude, km)
+	minCell := g.cellOf(bounds[0].Latitude, bounds[0].Longitude)
+	maxCell := g.cellOf(bounds[1].Latitude, bounds[1].Longitude)
+
+	//the longitude range crosses the antimeridian
+	lonRanges := [][2]int{{minCell.lon, maxCell.lon}}
+	if bounds[0].Longitude > bounds[1].Longitude {
+		lonRanges = [][2]int{
+			{minCell.lon, g.cellOf(0, 180).lon},
+			{g.cellOf(0, -180).lon, maxCell.lon},
+		}
+	}
+
+	//count the cells to visit, scanning the occupied cells is faster for very large radius
+	nbCells := 0
+	for _, r := range lonRanges {
+		nbCells += (maxCell.lat - minCell.lat + 1) * (r[1] - r[0] + 1)
+	}
+
+	if nbCells > len(g.cells) {
+		for c, indices := range g.cells {
+			if c.lat < minCell.lat || c.lat > maxCell.lat {
+				continue
+			}
+			for _, r := range lonRanges {
+				if c.lon >= r[0] && c.lon <= r[1] {
+					for _, i := range indices {
+						fn(i)
+					}
+					break
+				}
+			}
+		}
+		return
+	}
+
+	for lat := minCell.lat; lat <= maxCell.lat; lat++ {
+		for _, r := range lonRanges {
+			for lon := r[0]; lon <= r[1]; lon++ {
+				for _, i := range g.cells[cell{lat, lon}] {
+					fn(i)
+				}
+			}
+		}
+	}
+}
+
+//sortNeighbors sorts neighbors by distance then by id
+func sortNeighbors(neighbors []Neighbor) {
+	sort.Slice(neighbors, func(i, j int) bool {
+		if neighbors[i].Distance == neighbors[j].Distance {
+			return neighbors[i].ID < neighbors[j].ID
+		}
+		return neighbors[i].Distance < neighbors[j].Distance
+	})
+}
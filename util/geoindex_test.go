@@ -0,0 +1,121 @@
+package util
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func randomIndex(n int, minLat, maxLat, minLon, maxLon float64) (*GeoIndex, []Geolocation) {
+	rng := rand.New(rand.NewSource(42))
+	index := NewGeoIndex(2)
+	locations := make([]Geolocation, n)
+	for i := range locations {
+		locations[i] = Geolocation{
+			Latitude:  minLat + rng.Float64()*(maxLat-minLat),
+			Longitude: minLon + rng.Float64()*(maxLon-minLon),
+		}
+		index.Add(i, locations[i].Latitude, locations[i].Longitude)
+	}
+
+	return index, locations
+}
+
+//bruteForce returns the sorted neighbors of a location by computing all distances
+func bruteForce(locations []Geolocation, latitude, longitude float64) []Neighbor {
+	neighbors := make([]Neighbor, len(locations))
+	for i, l := range locations {
+		neighbors[i] = Neighbor{ID: i, Distance: DistanceTo(latitude, longitude, l.Latitude, l.Longitude)}
+	}
+	sortNeighbors(neighbors)
+	return neighbors
+}
+
+func TestGeoIndexWithin(t *testing.T) {
+	tests := []struct {
+		name                           string
+		minLat, maxLat, minLon, maxLon float64
+		query                          Geolocation
+	}{
+		{"netherlands", 50.75, 53.47, 3.36, 7.23, Geolocation{Latitude: 52.21, Longitude: 6.88}},
+		{"antimeridian", -17, -16, 179, 180, Geolocation{Latitude: -16.5, Longitude: -179.99}},
+		{"pole", 89, 90, -180, 180, Geolocation{Latitude: 89.99, Longitude: 0}},
+	}
+
+	for _, test := range tests {
+		index, locations := randomIndex(2000, test.minLat, test.maxLat, test.minLon, test.maxLon)
+		for _, km := range []float64{1, 10, 50} {
+			var expected []Neighbor
+			for _, n := range bruteForce(locations, test.query.Latitude, test.query.Longitude) {
+				if n.Distance <= km {
+					expected = append(expected, n)
+				}
+			}
+
+			neighbors := index.Within(test.query.Latitude, test.query.Longitude, km)
+			if len(neighbors) != len(expected) {
+				t.Errorf("Neighbors in %s within %.0f km are incorrect, got '%d', want '%d'", test.name, km, len(neighbors), len(expected))
+				continue
+			}
+			for i := range neighbors {
+				if neighbors[i] != expected[i] {
+					t.Errorf("Neighbor %d in %s is incorrect, got '%v', want '%v'", i, test.name, neighbors[i], expected[i])
+				}
+			}
+		}
+	}
+}
+
+func TestGeoIndexWithinExcludesCorners(t *testing.T) {
+	index := NewGeoIndex(1)
+	bounds := BoundingCoordinates(52.21, 6.88, 5)
+	index.Add(1, bounds[1].Latitude, bounds[1].Longitude)
+	index.Add(2, 52.23, 6.89)
+
+	neighbors := index.Within(52.21, 6.88, 5)
+	if len(neighbors) != 1 || neighbors[0].ID != 2 {
+		t.Errorf("Neighbors are incorrect, got '%v', want only the location 2", neighbors)
+	}
+}
+
+func TestGeoIndexNearest(t *testing.T) {
+	index, locations := randomIndex(5000, 50.75, 53.47, 3.36, 7.23)
+
+	for _, k := range []int{1, 10, 100, 6000} {
+		expected := bruteForce(locations, 52.21, 6.88)
+		if k < len(expected) {
+			expected = expected[:k]
+		}
+
+		neighbors := index.Nearest(52.21, 6.88, k)
+		if len(neighbors) != len(expected) {
+			t.Fatalf("Number of nearest neighbors is incorrect, got '%d', want '%d'", len(neighbors), len(expected))
+		}
+		if !sort.SliceIsSorted(neighbors, func(i, j int) bool { return neighbors[i].Distance < neighbors[j].Distance }) {
+			t.Error("Nearest neighbors should be sorted by distance")
+		}
+		for i := range neighbors {
+			if neighbors[i] != expected[i] {
+				t.Errorf("Nearest neighbor %d is incorrect, got '%v', want '%v'", i, neighbors[i], expected[i])
+			}
+		}
+	}
+}
+
+func BenchmarkGeoIndexWithin(b *testing.B) {
+	index, _ := randomIndex(1000000, 50.75, 53.47, 3.36, 7.23)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.Within(52.21, 6.88, 2)
+	}
+}
+
+func BenchmarkGeoIndexNearest(b *testing.B) {
+	index, _ := randomIndex(1000000, 50.75, 53.47, 3.36, 7.23)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.Nearest(52.21, 6.88, 20)
+	}
+}
@@ -47,3 +47,10 @@ func TestBoundingCoordinates(t *testing.T) {
 		t.Errorf("Boundig Coordinates are incorrect")
 	}
 }
+
+func TestDistanceToSameLocation(t *testing.T) {
+	dist := DistanceTo(52.210976, 6.883238, 52.210976, 6.883238)
+	if dist != 0 {
+		t.Errorf("Distance is incorrect, got '%f', want '0'", dist)
+	}
+}
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//Municipality contains the population and the centroid of a municipality
+type Municipality struct {
+	Name       string
+	Population int
+	Location   Geolocation
+}
+
+//LoadMunicipalities loads municipalities from a CSV file with the columns municipality, population, latitude and longitude
+func LoadMunicipalities(path string) ([]Municipality, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid municipalities dataset %s: %v", path, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("municipalities dataset %s is empty", path)
+	}
+
+	//columns
+	columns := make(map[string]int)
+	for i, n := range records[0] {
+		columns[strings.TrimSpace(n)] = i
+	}
+	for _, n := range []string{"municipality", "population", "latitude", "longitude"} {
+		if _, ok := columns[n]; !ok {
+			return nil, fmt.Errorf("municipalities dataset %s has no %s column", path, n)
+		}
+	}
+
+	municipalities := make([]Municipality, len(records)-1)
+	for i, record := range records[1:] {
+		population, err := strconv.Atoi(record[columns["population"]])
+		if err != nil || population < 0 {
+			return nil, fmt.Errorf("line %d: invalid population %s", i+2, record[columns["population"]])
+		}
+		latitude, err := strconv.ParseFloat(record[columns["latitude"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+2, err)
+		}
+		longitude, err := strconv.ParseFloat(record[columns["longitude"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+2, err)
+		}
+
+		municipalities[i] = Municipality{
+			Name:       record[columns["municipality"]],
+			Population: population,
+			Location:   Geolocation{Latitude: latitude, Longitude: longitude},
+		}
+	}
+
+	return municipalities, nil
+}
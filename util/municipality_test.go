@@ -0,0 +1,28 @@
+package util
+
+import "testing"
+
+func TestLoadMunicipalities(t *testing.T) {
+	municipalities, err := LoadMunicipalities("../config/municipalities_sample.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//every municipality of the postcodes dataset has a population
+	postcodes, err := LoadPostcodes("../config/postcodes_sample.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	populated := make(map[string]bool)
+	for _, m := range municipalities {
+		if m.Population <= 0 {
+			t.Errorf("Population of %s is incorrect, got '%d'", m.Name, m.Population)
+		}
+		populated[m.Name] = true
+	}
+	for _, pc := range postcodes.Finest() {
+		if !populated[pc.Municipality] {
+			t.Errorf("Municipality %s of postcode %s has no population", pc.Municipality, pc.Code)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package util
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//ErrUnknownPostcode is returned when a postcode is not in the dataset
+var ErrUnknownPostcode = errors.New("unknown postcode")
+
+//Postcode contains the centroid of a Dutch postcode area
+type Postcode struct {
+	//Code is a PC4 (1234) or a PC6 (1234AB) postcode
+	Code         string
+	Location     Geolocation
+	Municipality string
+}
+
+//Postcodes maps Dutch postcodes to the centroid of their area
+type Postcodes struct {
+	//path of the dataset, reported with unknown postcodes
+	path  string
+	codes map[string]Postcode
+	//sorted codes, PC6 postcodes when the dataset has them for a PC4 area, PC4 postcodes otherwise
+	finest []string
+}
+
+var postcodeRegexp = regexp.MustCompile(`^[1-9][0-9]{3}([A-Z]{2})?$`)
+
+//NormalizePostcode returns a postcode without spaces and in upper case, as 1234 or 1234AB
+func NormalizePostcode(postcode string) (string, error) {
+	code := strings.ToUpper(strings.Join(strings.Fields(postcode), ""))
+	if !postcodeRegexp.MatchString(code) {
+		return "", fmt.Errorf("invalid postcode %s", postcode)
+	}
+
+	return code, nil
+}
+
+//LoadPostcodes loads a postcodes dataset from a CSV file with the columns postcode, latitude, longitude and municipality
+//the PC4 areas missing from the dataset are located at the centroid of their PC6 postcodes
+func LoadPostcodes(path string) (*Postcodes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid postcodes dataset %s: %v", path, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("postcodes dataset %s is empty", path)
+	}
+
+	//columns
+	columns := make(map[string]int)
+	for i, n := range records[0] {
+		columns[strings.TrimSpace(n)] = i
+	}
+	for _, n := range []string{"postcode", "latitude", "longitude", "municipality"} {
+		if _, ok := columns[n]; !ok {
+			return nil, fmt.Errorf("postcodes dataset %s has no %s column", path, n)
+		}
+	}
+
+	p := &Postcodes{path: path, codes: make(map[string]Postcode)}
+	for i, record := range records[1:] {
+		code, err := NormalizePostcode(record[columns["postcode"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+2, err)
+		}
+		latitude, err := strconv.ParseFloat(record[columns["latitude"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+2, err)
+		}
+		longitude, err := strconv.ParseFloat(record[columns["longitude"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+2, err)
+		}
+
+		p.codes[code] = Postcode{
+			Code:         code,
+			Location:     Geolocation{Latitude: latitude, Longitude: longitude},
+			Municipality: record[columns["municipality"]],
+		}
+	}
+
+	//centroid of the PC4 areas only known by their PC6 postcodes
+	type centroid struct {
+		sum   Geolocation
+		count int
+		pc6   Postcode
+	}
+	centroids := make(map[string]*centroid)
+	for code, pc := range p.codes {
+		if len(code) == 4 {
+			continue
+		}
+		c, ok := centroids[code[:4]]
+		if !ok {
+			c = &centroid{pc6: pc}
+			centroids[code[:4]] = c
+		}
+		c.sum.Latitude += pc.Location.Latitude
+		c.sum.Longitude += pc.Location.Longitude
+		c.count++
+	}
+	for pc4, c := range centroids {
+		if _, ok := p.codes[pc4]; ok {
+			continue
+		}
+		p.codes[pc4] = Postcode{
+			Code: pc4,
+			Location: Geolocation{
+				Latitude:  c.sum.Latitude / float64(c.count),
+				Longitude: c.sum.Longitude / float64(c.count),
+			},
+			Municipality: c.pc6.Municipality,
+		}
+	}
+
+	for code := range p.codes {
+		if len(code) == 6 || centroids[code] == nil {
+			p.finest = append(p.finest, code)
+		}
+	}
+	sort.Strings(p.finest)
+
+	return p, nil
+}
+
+//Len returns the number of postcodes of the dataset
+func (p *Postcodes) Len() int {
+	return len(p.codes)
+}
+
+//Lookup returns a postcode of the dataset, a PC6 postcode missing from the dataset falls back on its PC4 area
+func (p *Postcodes) Lookup(postcode string) (Postcode, error) {
+	code, err := NormalizePostcode(postcode)
+	if err != nil {
+		return Postcode{}, err
+	}
+
+	if pc, ok := p.codes[code]; ok {
+		return pc, nil
+	}
+	if pc, ok := p.codes[code[:4]]; ok {
+		return pc, nil
+	}
+
+	return Postcode{}, fmt.Errorf("%w: %s is not in the postcodes dataset %s", ErrUnknownPostcode, postcode, p.path)
+}
+
+//Geocode returns the centroid of a postcode
+func (p *Postcodes) Geocode(postcode string) (Geolocation, error) {
+	pc, err := p.Lookup(postcode)
+	if err != nil {
+		return Geolocation{}, err
+	}
+
+	return pc.Location, nil
+}
+
+//Finest returns the most precise postcodes of the dataset, sorted by code
+//a PC4 area is represented by its PC6 postcodes when the dataset has them
+func (p *Postcodes) Finest() []Postcode {
+	postcodes := make([]Postcode, len(p.finest))
+	for i, code := range p.finest {
+		postcodes[i] = p.codes[code]
+	}
+
+	return postcodes
+}
+
+//InMunicipality returns the most precise postcodes of a municipality, sorted by code
+func (p *Postcodes) InMunicipality(municipality string) []Postcode {
+	var postcodes []Postcode
+	for _, code := range p.finest {
+		if p.codes[code].Municipality == municipality {
+			postcodes = append(postcodes, p.codes[code])
+		}
+	}
+
+	return postcodes
+}
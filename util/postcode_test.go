@@ -0,0 +1,77 @@
+package util
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePostcode(t *testing.T) {
+	valid := map[string]string{
+		"7522 NB": "7522NB",
+		"7522nb":  "7522NB",
+		" 7522 ":  "7522",
+	}
+	for input, expected := range valid {
+		code, err := NormalizePostcode(input)
+		if err != nil || code != expected {
+			t.Errorf("Postcode is incorrect, got '%s' (%v), want '%s'", code, err, expected)
+		}
+	}
+
+	for _, input := range []string{"0123AB", "7522N", "75221", "NB7522", ""} {
+		if _, err := NormalizePostcode(input); err == nil {
+			t.Errorf("Postcode %s should be invalid", input)
+		}
+	}
+}
+
+func TestPostcodes(t *testing.T) {
+	postcodes, err := LoadPostcodes("../config/postcodes_sample.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//PC4 postcode
+	l, err := postcodes.Geocode("1012")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if DistanceTo(l.Latitude, l.Longitude, 52.3738, 4.8910) > 0.001 {
+		t.Errorf("Location of 1012 is incorrect, got '%v'", l)
+	}
+
+	//unknown PC6 postcode falls back on its PC4 area
+	pc, err := postcodes.Lookup("7511 AA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pc.Code != "7511" || pc.Municipality != "Enschede" {
+		t.Errorf("Postcode of 7511AA is incorrect, got '%v', want '7511'", pc)
+	}
+
+	//PC4 area only known by its PC6 postcodes
+	pc, err = postcodes.Lookup("7522")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Geolocation{Latitude: (52.2396 + 52.2435 + 52.2424) / 3, Longitude: (6.8500 + 6.8523 + 6.8465) / 3}
+	if math.Abs(pc.Location.Latitude-expected.Latitude) > 1e-9 || math.Abs(pc.Location.Longitude-expected.Longitude) > 1e-9 {
+		t.Errorf("Location of 7522 is incorrect, got '%v', want '%v'", pc.Location, expected)
+	}
+
+	if _, err := postcodes.Geocode("1234"); !errors.Is(err, ErrUnknownPostcode) || !strings.Contains(err.Error(), "postcodes_sample.csv") {
+		t.Errorf("Postcode 1234 should be unknown in the sample, got '%v'", err)
+	}
+
+	//the PC4 area 7522 is represented by its PC6 postcodes
+	for _, pc := range postcodes.Finest() {
+		if pc.Code == "7522" {
+			t.Error("Finest postcodes should not contain PC4 areas with PC6 postcodes")
+		}
+	}
+	if len(postcodes.Finest()) != postcodes.Len()-1 {
+		t.Errorf("Number of finest postcodes is incorrect, got '%d', want '%d'", len(postcodes.Finest()), postcodes.Len()-1)
+	}
+}
@@ -0,0 +1,242 @@
+package util
+
+import (
+	"container/heap"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/julienrbrt/ut_research_project/geo"
+)
+
+//TravelMode defines the roads usable to travel and at which speed
+type TravelMode struct {
+	Name     string
+	SpeedKmh float64
+	//Highways contains the usable values of the highway tag of OpenStreetMap ways
+	Highways map[string]bool
+	//Oneway defines if oneway roads are only travelled in their direction
+	Oneway bool
+}
+
+//Walking and Cycling are the travel modes of pedestrians and cyclists
+var (
+	Walking = TravelMode{
+		Name:     "walk",
+		SpeedKmh: 5,
+		Highways: highways("primary", "secondary", "tertiary", "unclassified", "residential", "living_street", "service", "pedestrian", "footway", "path", "steps", "track",
+			"primary_link", "secondary_link", "tertiary_link"),
+	}
+	Cycling = TravelMode{
+		Name:     "cycle",
+		SpeedKmh: 15,
+		Highways: highways("primary", "secondary", "tertiary", "unclassified", "residential", "living_street", "service", "cycleway", "path", "track",
+			"primary_link", "secondary_link", "tertiary_link"),
+		Oneway: true,
+	}
+)
+
+func highways(values ...string) map[string]bool {
+	set := make(map[string]bool)
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+//RoadGraph is a road network loaded from an OpenStreetMap extract, weighted by the travel time in minutes of a travel mode
+type RoadGraph struct {
+	Mode  TravelMode
+	nodes []Geolocation
+	edges [][]edge
+	//nodes spatial index to snap locations on the graph
+	index *GeoIndex
+}
+
+type edge struct {
+	to      int
+	minutes float64
+}
+
+//osm elements
+type osmNode struct {
+	ID  int64   `xml:"id,attr"`
+	Lat float64 `xml:"lat,attr"`
+	Lon float64 `xml:"lon,attr"`
+}
+
+type osmWay struct {
+	Nodes []struct {
+		Ref int64 `xml:"ref,attr"`
+	} `xml:"nd"`
+	Tags []struct {
+		Key   string `xml:"k,attr"`
+		Value string `xml:"v,attr"`
+	} `xml:"tag"`
+}
+
+//LoadOSM loads the road graph of a travel mode from an OpenStreetMap XML extract (.osm)
+func LoadOSM(path string, mode TravelMode) (*RoadGraph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	g, err := ReadOSM(f, mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenStreetMap extract %s: %v", path, err)
+	}
+
+	return g, nil
+}
+
+//ReadOSM reads the road graph of a travel mode from OpenStreetMap XML
+func ReadOSM(r io.Reader, mode TravelMode) (*RoadGraph, error) {
+	if mode.SpeedKmh <= 0 {
+		return nil, errors.New("travel mode speed must be positive")
+	}
+
+	g := &RoadGraph{
+		Mode:  mode,
+		index: NewGeoIndex(0.5),
+	}
+	locations := make(map[int64]Geolocation)
+	graphNodes := make(map[int64]int)
+
+	//graph node of an OpenStreetMap node
+	node := func(id int64) (int, bool) {
+		if i, ok := graphNodes[id]; ok {
+			return i, true
+		}
+		l, ok := locations[id]
+		if !ok {
+			return 0, false
+		}
+		i := len(g.nodes)
+		graphNodes[id] = i
+		g.nodes = append(g.nodes, l)
+		g.edges = append(g.edges, nil)
+		g.index.Add(i, l.Latitude, l.Longitude)
+		return i, true
+	}
+
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "node":
+			var n osmNode
+			if err := decoder.DecodeElement(&n, &start); err != nil {
+				return nil, err
+			}
+			locations[n.ID] = Geolocation{Latitude: n.Lat, Longitude: n.Lon}
+		case "way":
+			var w osmWay
+			if err := decoder.DecodeElement(&w, &start); err != nil {
+				return nil, err
+			}
+
+			tags := make(map[string]string)
+			for _, t := range w.Tags {
+				tags[t.Key] = t.Value
+			}
+			if !mode.Highways[tags["highway"]] {
+				continue
+			}
+			oneway := mode.Oneway && (tags["oneway"] == "yes" || tags["oneway"] == "1") && tags["oneway:bicycle"] != "no"
+
+			//connect consecutive nodes of the way
+			for i := 1; i < len(w.Nodes); i++ {
+				from, ok := node(w.Nodes[i-1].Ref)
+				if !ok {
+					continue
+				}
+				to, ok := node(w.Nodes[i].Ref)
+				if !ok {
+					continue
+				}
+
+				minutes := geo.Haversine(g.nodes[from], g.nodes[to]) / mode.SpeedKmh * 60
+				g.edges[from] = append(g.edges[from], edge{to: to, minutes: minutes})
+				if !oneway {
+					g.edges[to] = append(g.edges[to], edge{to: from, minutes: minutes})
+				}
+			}
+		}
+	}
+
+	if len(g.nodes) == 0 {
+		return nil, fmt.Errorf("no road usable to %s", mode.Name)
+	}
+
+	return g, nil
+}
+
+//Len returns the number of nodes of the graph
+func (g *RoadGraph) Len() int {
+	return len(g.nodes)
+}
+
+//Snap returns the closest node of the graph to a location and the minutes needed to reach it in a straight line
+func (g *RoadGraph) Snap(latitude, longitude float64) (node int, minutes float64) {
+	closest := g.index.Nearest(latitude, longitude, 1)
+	return closest[0].ID, closest[0].Distance / g.Mode.SpeedKmh * 60
+}
+
+//Isochrone returns the nodes reachable from a node in at most the given minutes and the minutes needed to reach them
+func (g *RoadGraph) Isochrone(from int, minutes float64) map[int]float64 {
+	reached := make(map[int]float64)
+	queue := &nodeQueue{{node: from, minutes: 0}}
+
+	//Dijkstra stopped at the time budget
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(queuedNode)
+		if _, ok := reached[current.node]; ok {
+			continue
+		}
+		reached[current.node] = current.minutes
+
+		for _, e := range g.edges[current.node] {
+			t := current.minutes + e.minutes
+			if _, ok := reached[e.to]; !ok && t <= minutes {
+				heap.Push(queue, queuedNode{node: e.to, minutes: t})
+			}
+		}
+	}
+
+	return reached
+}
+
+type queuedNode struct {
+	node    int
+	minutes float64
+}
+
+//nodeQueue is a priority queue of nodes by travel time
+type nodeQueue []queuedNode
+
+func (q nodeQueue) Len() int            { return len(q) }
+func (q nodeQueue) Less(i, j int) bool  { return q[i].minutes < q[j].minutes }
+func (q nodeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x interface{}) { *q = append(*q, x.(queuedNode)) }
+func (q *nodeQueue) Pop() interface{} {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
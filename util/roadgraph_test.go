@@ -0,0 +1,72 @@
+package util
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+//three nodes on a residential street, a motorway shortcut and a oneway cycleway
+const testOSM = `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6">
+	<node id="1" lat="52.0" lon="6.00"/>
+	<node id="2" lat="52.0" lon="6.01"/>
+	<node id="3" lat="52.0" lon="6.02"/>
+	<node id="4" lat="52.0" lon="6.03"/>
+	<way id="10">
+		<nd ref="1"/><nd ref="2"/><nd ref="3"/>
+		<tag k="highway" v="residential"/>
+	</way>
+	<way id="11">
+		<nd ref="1"/><nd ref="3"/>
+		<tag k="highway" v="motorway"/>
+	</way>
+	<way id="12">
+		<nd ref="3"/><nd ref="4"/>
+		<tag k="highway" v="cycleway"/>
+		<tag k="oneway" v="yes"/>
+	</way>
+</osm>`
+
+func TestReadOSM(t *testing.T) {
+	walking, err := ReadOSM(strings.NewReader(testOSM), Walking)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if walking.Len() != 3 {
+		t.Errorf("Number of walkable nodes is incorrect, got '%d', want '3'", walking.Len())
+	}
+
+	//the motorway is not walkable
+	from, access := walking.Snap(52.0, 6.0)
+	if access != 0 {
+		t.Errorf("Access time is incorrect, got '%f', want '0'", access)
+	}
+	if reached := walking.Isochrone(from, 10); len(reached) != 2 {
+		t.Errorf("Nodes reached in 10 minutes are incorrect, got '%v', want 2 nodes", reached)
+	}
+	to, _ := walking.Snap(52.0, 6.02)
+	reached := walking.Isochrone(from, 20)
+	expected := DistanceTo(52.0, 6.0, 52.0, 6.02) / Walking.SpeedKmh * 60
+	if math.Abs(reached[to]-expected) > 1e-6 {
+		t.Errorf("Travel time is incorrect, got '%f', want '%f'", reached[to], expected)
+	}
+
+	//the cycleway is oneway
+	cycling, err := ReadOSM(strings.NewReader(testOSM), Cycling)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, _ := cycling.Snap(52.0, 6.02)
+	end, _ := cycling.Snap(52.0, 6.03)
+	if _, ok := cycling.Isochrone(start, 10)[end]; !ok {
+		t.Error("End of the oneway cycleway should be reachable")
+	}
+	if _, ok := cycling.Isochrone(end, 10)[start]; ok {
+		t.Error("Start of the oneway cycleway should not be reachable")
+	}
+
+	if _, err := ReadOSM(strings.NewReader(`<osm></osm>`), Walking); err == nil {
+		t.Error("Expected an error for an extract without roads")
+	}
+}
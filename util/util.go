@@ -12,6 +12,9 @@ import (
 	"github.com/go-gota/gota/dataframe"
 )
 
+//DateLayout is the layout of the dates stored in the CSV files
+const DateLayout = "2006-01-02 15:04:05"
+
 //RemoveDuplicatesUnordered removes duplicates and ignores order
 func RemoveDuplicatesUnordered(elements []string) []string {
 	encountered := map[string]bool{}
@@ -127,3 +130,53 @@ func SS2SF(values []string) ([]float64, error) {
 
 	return slice, nil
 }
+
+//ContainsKeyword returns if a name (e.g. an ingredient, with words separated by spaces, _ or -) contains one of the keywords
+//a keyword matches a word when they are equal, or when the word ends with a keyword of at least 4 letters
+//as Dutch compound words end with their main noun (tarwebloem but not bloemkool)
+func ContainsKeyword(name string, keywords []string) bool {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	})
+	for _, w := range words {
+		for _, k := range keywords {
+			if w == k || (len([]rune(k)) >= 4 && strings.HasSuffix(w, k)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//ContainsCompound returns if a name (with words separated by spaces, _ or -) contains one of the keywords in one of its words
+//Dutch compound words start or end with their nouns (kipdijfilet, tarwebloem), so a keyword matches the start or the end of
+//a word, or any part of it for keywords of at least 4 letters (runderriblappen)
+//words containing one of the exceptions are ignored, as they contain a keyword without being one (bloemkool, kokosmelk)
+func ContainsCompound(name string, keywords, exceptions []string) bool {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	})
+	for _, w := range words {
+		if containsAny(w, exceptions) {
+			continue
+		}
+		for _, k := range keywords {
+			if strings.HasPrefix(w, k) || strings.HasSuffix(w, k) || (len([]rune(k)) >= 4 && strings.Contains(w, k)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//containsAny returns if a word contains one of the parts
+func containsAny(word string, parts []string) bool {
+	for _, p := range parts {
+		if strings.Contains(word, p) {
+			return true
+		}
+	}
+	return false
+}
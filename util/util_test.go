@@ -53,3 +53,37 @@ func TestCosineSimilarity(t *testing.T) {
 		t.Error("Expected similarity of 0, got instead ", cos)
 	}
 }
+
+func TestContainsKeyword(t *testing.T) {
+	if !ContainsKeyword("geraspte_kaas", []string{"kaas"}) || !ContainsKeyword("tarwebloem", []string{"bloem"}) {
+		t.Error("Expected the keyword to be found")
+	}
+	if ContainsKeyword("bloemkool", []string{"bloem"}) || ContainsKeyword("prei", []string{"ei"}) {
+		t.Error("Expected the keyword not to be found")
+	}
+}
+
+func TestContainsCompound(t *testing.T) {
+	tests := []struct {
+		name       string
+		keywords   []string
+		exceptions []string
+		want       bool
+	}{
+		{"kipdijfilet", []string{"kip"}, nil, true},
+		{"serrano_ham", []string{"ham"}, nil, true},
+		{"runderriblappen", []string{"rund"}, nil, true},
+		{"geraspte_kaas", []string{"kaas"}, nil, true},
+		{"prei", []string{"ei"}, nil, true},
+		{"prei", []string{"ei"}, []string{"prei"}, false},
+		{"kokosmelk", []string{"melk"}, []string{"kokosmelk"}, false},
+		{"halfvolle_melk", []string{"melk"}, []string{"kokosmelk"}, true},
+		{"spinazie", []string{"pin"}, nil, false},
+	}
+
+	for _, test := range tests {
+		if got := ContainsCompound(test.name, test.keywords, test.exceptions); got != test.want {
+			t.Errorf("Compound of %s is incorrect, got '%v', want '%v'", test.name, got, test.want)
+		}
+	}
+}